@@ -0,0 +1,531 @@
+// Package config loads a service's typed configuration. Values are taken,
+// in increasing order of precedence, from the defaults already set in the
+// struct, a YAML or TOML file, environment variables and command-line flags.
+//
+// Fields are described with struct tags:
+//
+//	key:"name"      name in the file; dotted with its parents, the flag name
+//	env:"A,B"       environment variables, the first one set wins
+//	usage:"text"    flag help
+//	secret:"true"   redacted by --print-config
+//
+// A struct field with a key tag is a section of nested settings. Leaf
+// fields may be strings, bools, ints, floats, time.Duration, []string
+// (comma-separated in env and flags) or maps from string to one of those
+// scalars ("name=value,other=value" in env and flags). Fields without a
+// key tag are ignored.
+//
+// Every service built on Load accepts the same built-in flags: --config
+// (or CONFIG_FILE) names the file, and --print-config prints the effective
+// configuration, noting where each value came from, and exits.
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Validator is implemented by configuration structs that check their own
+// values. Load calls Validate on every section, innermost first.
+type Validator interface {
+	Validate() error
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// field is one leaf setting.
+type field struct {
+	path   string // dotted file key
+	env    []string
+	usage  string
+	secret bool
+	value  reflect.Value
+	source string // where the current value came from
+}
+
+// tree indexes a configuration struct's settings by dotted path.
+type tree struct {
+	fields   []*field
+	byPath   map[string]*field
+	sections map[string]bool
+}
+
+// Load fills cfg, a pointer to a struct holding the defaults, from the
+// configuration file, the environment and os.Args, then validates it.
+// Invalid flags, -h and --print-config exit the process.
+func Load(cfg any) error {
+	return load(cfg, filepath.Base(os.Args[0]), os.Args[1:], os.LookupEnv, os.Stdout)
+}
+
+func load(cfg any, name string, args []string, lookup func(string) (string, bool), stdout io.Writer) error {
+	rv := reflect.ValueOf(cfg)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config: want a pointer to a struct, got %T", cfg)
+	}
+	t := &tree{byPath: make(map[string]*field), sections: make(map[string]bool)}
+	if err := t.collect(rv.Elem(), ""); err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	file := fs.String("config", "", "YAML or TOML configuration file (env CONFIG_FILE)")
+	printConfig := fs.Bool("print-config", false, "print the effective configuration and exit")
+	flags := make(map[string]*field)
+	for _, f := range t.fields {
+		usage := f.usage
+		if len(f.env) > 0 {
+			usage += fmt.Sprintf(" (env %s)", strings.Join(f.env, ", "))
+		}
+		fs.Var(&flagValue{
+			value:  format(f.value),
+			isBool: f.value.Kind() == reflect.Bool,
+		}, flagName(f.path), strings.TrimSpace(usage))
+		flags[flagName(f.path)] = f
+	}
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		return fmt.Errorf("config: unexpected argument %q", fs.Arg(0))
+	}
+
+	path := *file
+	if path == "" {
+		path, _ = lookup("CONFIG_FILE")
+	}
+	if path != "" {
+		if err := t.applyFile(path); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range t.fields {
+		for _, env := range f.env {
+			if v, ok := lookup(env); ok && v != "" {
+				if err := set(f.value, v); err != nil {
+					return fmt.Errorf("config: %s: %w", env, err)
+				}
+				f.source = "env " + env
+				break
+			}
+		}
+	}
+
+	var err error
+	fs.Visit(func(fl *flag.Flag) {
+		f, ok := flags[fl.Name]
+		if !ok || err != nil {
+			return
+		}
+		if err = set(f.value, fl.Value.String()); err != nil {
+			err = fmt.Errorf("config: --%s: %w", fl.Name, err)
+			return
+		}
+		f.source = "flag --" + fl.Name
+	})
+	if err != nil {
+		return err
+	}
+
+	err = validate(rv.Elem(), "")
+	if *printConfig {
+		if perr := t.print(stdout, rv.Elem()); perr != nil {
+			return perr
+		}
+		if err == nil {
+			os.Exit(0)
+		}
+	}
+	return err
+}
+
+// flagName turns a dotted file path into a flag name: "kafka.group_id"
+// becomes "kafka.group-id".
+func flagName(path string) string {
+	return strings.ReplaceAll(path, "_", "-")
+}
+
+// flagValue records a flag's text; it is parsed once the file and the
+// environment have been applied, so that flags win.
+type flagValue struct {
+	value  string
+	isBool bool
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.value
+}
+
+func (v *flagValue) Set(s string) error {
+	v.value = s
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool { return v.isBool }
+
+func (t *tree) collect(v reflect.Value, prefix string) error {
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		sf := typ.Field(i)
+		key := sf.Tag.Get("key")
+		if key == "" || !sf.IsExported() {
+			continue
+		}
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			t.sections[path] = true
+			if err := t.collect(fv, path); err != nil {
+				return err
+			}
+			continue
+		}
+		if !supported(fv.Type()) {
+			return fmt.Errorf("config: %s: unsupported type %s", path, fv.Type())
+		}
+		var env []string
+		for _, name := range strings.Split(sf.Tag.Get("env"), ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				env = append(env, name)
+			}
+		}
+		f := &field{
+			path:   path,
+			env:    env,
+			usage:  sf.Tag.Get("usage"),
+			secret: sf.Tag.Get("secret") == "true",
+			value:  fv,
+			source: "default",
+		}
+		t.fields = append(t.fields, f)
+		t.byPath[path] = f
+	}
+	return nil
+}
+
+func scalar(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func supported(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.String
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && scalar(t.Elem().Kind())
+	}
+	return scalar(t.Kind())
+}
+
+// set parses s into v using the env/flag syntax.
+func set(v reflect.Value, s string) error {
+	switch v.Kind() {
+	case reflect.Slice:
+		var parts []string
+		for _, p := range strings.Split(s, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				parts = append(parts, p)
+			}
+		}
+		v.Set(reflect.ValueOf(parts).Convert(v.Type()))
+		return nil
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		for _, pair := range strings.Split(s, ",") {
+			if strings.TrimSpace(pair) == "" {
+				continue
+			}
+			name, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("%q: want name=value", pair)
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setScalar(elem, value); err != nil {
+				return fmt.Errorf("%q: %w", pair, err)
+			}
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(name)).Convert(v.Type().Key()), elem)
+		}
+		v.Set(m)
+		return nil
+	}
+	return setScalar(v, s)
+}
+
+func setScalar(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(s), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// applyFile decodes a .yaml, .yml or .toml file over the current values.
+// Unknown keys are an error so that typos do not pass silently.
+func (t *tree) applyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	m := make(map[string]any)
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &m)
+	case ".toml":
+		err = toml.Unmarshal(data, &m)
+	default:
+		return fmt.Errorf("config: %s: unsupported file type %q (want .yaml, .yml or .toml)", path, ext)
+	}
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+	return t.applyMap(m, "", "file "+path)
+}
+
+func (t *tree) applyMap(m map[string]any, prefix, source string) error {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		if f, ok := t.byPath[path]; ok {
+			if err := setFromFile(f.value, m[k]); err != nil {
+				return fmt.Errorf("config: %s: %s: %w", source, path, err)
+			}
+			f.source = source
+			continue
+		}
+		if t.sections[path] {
+			sub, ok := m[k].(map[string]any)
+			if !ok {
+				return fmt.Errorf("config: %s: %s: want a section", source, path)
+			}
+			if err := t.applyMap(sub, path, source); err != nil {
+				return err
+			}
+			continue
+		}
+		return fmt.Errorf("config: %s: unknown key %q", source, path)
+	}
+	return nil
+}
+
+func setFromFile(v reflect.Value, raw any) error {
+	switch v.Kind() {
+	case reflect.Slice:
+		list, ok := raw.([]any)
+		if !ok {
+			s, err := scalarText(raw)
+			if err != nil {
+				return err
+			}
+			return set(v, s)
+		}
+		parts := make([]string, len(list))
+		for i, item := range list {
+			s, err := scalarText(item)
+			if err != nil {
+				return err
+			}
+			parts[i] = s
+		}
+		v.Set(reflect.ValueOf(parts).Convert(v.Type()))
+		return nil
+	case reflect.Map:
+		src, ok := raw.(map[string]any)
+		if !ok {
+			return fmt.Errorf("want a mapping")
+		}
+		m := reflect.MakeMap(v.Type())
+		for name, item := range src {
+			s, err := scalarText(item)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setScalar(elem, s); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			m.SetMapIndex(reflect.ValueOf(name).Convert(v.Type().Key()), elem)
+		}
+		v.Set(m)
+		return nil
+	}
+	s, err := scalarText(raw)
+	if err != nil {
+		return err
+	}
+	return setScalar(v, s)
+}
+
+// scalarText renders a decoded file value so it can be parsed like an
+// environment variable.
+func scalarText(raw any) (string, error) {
+	switch r := raw.(type) {
+	case string:
+		return r, nil
+	case bool, int, int64, uint64:
+		return fmt.Sprint(r), nil
+	case float64:
+		return strconv.FormatFloat(r, 'f', -1, 64), nil
+	}
+	return "", fmt.Errorf("want a single value, got %T", raw)
+}
+
+// validate runs Validate on v's sections and then on v itself.
+func validate(v reflect.Value, path string) error {
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		key := typ.Field(i).Tag.Get("key")
+		if key == "" || v.Field(i).Kind() != reflect.Struct {
+			continue
+		}
+		sub := key
+		if path != "" {
+			sub = path + "." + key
+		}
+		if err := validate(v.Field(i), sub); err != nil {
+			return err
+		}
+	}
+	if val, ok := v.Addr().Interface().(Validator); ok {
+		if err := val.Validate(); err != nil {
+			if path == "" {
+				return fmt.Errorf("config: %w", err)
+			}
+			return fmt.Errorf("config: %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// format renders v in the env/flag syntax.
+func format(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.Slice:
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return strings.Join(parts, ",")
+	case v.Kind() == reflect.Map:
+		pairs := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			pairs = append(pairs, fmt.Sprintf("%v=%v", k.Interface(), v.MapIndex(k).Interface()))
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// print writes the configuration as YAML, each value commented with its
+// source. Secrets that are set are replaced by "<redacted>".
+func (t *tree) print(w io.Writer, v reflect.Value) error {
+	root, err := t.node(v, "")
+	if err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func (t *tree) node(v reflect.Value, prefix string) (*yaml.Node, error) {
+	n := &yaml.Node{Kind: yaml.MappingNode}
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		key := typ.Field(i).Tag.Get("key")
+		if key == "" || !typ.Field(i).IsExported() {
+			continue
+		}
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		var value *yaml.Node
+		if f, ok := t.byPath[path]; ok {
+			value = &yaml.Node{}
+			switch {
+			case f.secret && !f.value.IsZero():
+				value.SetString("<redacted>")
+			case f.value.Type() == durationType:
+				value.SetString(format(f.value))
+			default:
+				if err := value.Encode(f.value.Interface()); err != nil {
+					return nil, fmt.Errorf("config: %s: %w", path, err)
+				}
+				if value.Kind != yaml.ScalarNode {
+					value.Style = yaml.FlowStyle
+				}
+			}
+			value.LineComment = f.source
+		} else {
+			sub, err := t.node(v.Field(i), path)
+			if err != nil {
+				return nil, err
+			}
+			value = sub
+		}
+		n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	}
+	return n, nil
+}
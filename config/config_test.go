@@ -0,0 +1,214 @@
+package config
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testDB struct {
+	URL      string `key:"url" env:"TEST_DB_URL" secret:"true"`
+	Pool     int    `key:"pool" env:"TEST_DB_POOL"`
+	Password string `key:"password" env:"TEST_DB_PASSWORD" secret:"true"`
+}
+
+func (d *testDB) Validate() error {
+	if d.Pool < 1 {
+		return errors.New("pool: must be at least 1")
+	}
+	return nil
+}
+
+type testConfig struct {
+	Name    string            `key:"name" env:"TEST_NAME,TEST_LEGACY_NAME"`
+	Timeout time.Duration     `key:"timeout" env:"TEST_TIMEOUT"`
+	Debug   bool              `key:"debug" env:"TEST_DEBUG"`
+	Tags    []string          `key:"tags" env:"TEST_TAGS"`
+	Limits  map[string]int    `key:"limits" env:"TEST_LIMITS"`
+	DB      testDB            `key:"db"`
+	Ignored string            // no key tag
+	Labels  map[string]string `key:"labels"`
+}
+
+func defaultTestConfig() testConfig {
+	return testConfig{Name: "default", Timeout: time.Second, DB: testDB{Pool: 1}}
+}
+
+// env returns a lookup function over vars.
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := vars[k]
+		return v, ok
+	}
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	yamlFile := writeFile(t, "config.yaml", `
+name: from-file
+timeout: 5s
+tags: [a, b]
+db:
+  pool: 4
+`)
+	tomlFile := writeFile(t, "config.toml", `
+name = "from-toml"
+limits = { upload = 10 }
+
+[db]
+pool = 8
+`)
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		want func(*testConfig)
+	}{
+		{"defaults", nil, nil, func(c *testConfig) {}},
+		{"file over default", nil, []string{"--config", yamlFile}, func(c *testConfig) {
+			c.Name, c.Timeout, c.Tags, c.DB.Pool = "from-file", 5*time.Second, []string{"a", "b"}, 4
+		}},
+		{"CONFIG_FILE names the file", map[string]string{"CONFIG_FILE": tomlFile}, nil, func(c *testConfig) {
+			c.Name, c.Limits, c.DB.Pool = "from-toml", map[string]int{"upload": 10}, 8
+		}},
+		{"--config over CONFIG_FILE", map[string]string{"CONFIG_FILE": tomlFile}, []string{"--config", yamlFile}, func(c *testConfig) {
+			c.Name, c.Timeout, c.Tags, c.DB.Pool = "from-file", 5*time.Second, []string{"a", "b"}, 4
+		}},
+		{"env over file", map[string]string{"TEST_NAME": "from-env", "TEST_DB_POOL": "6"}, []string{"--config", yamlFile}, func(c *testConfig) {
+			c.Name, c.Timeout, c.Tags, c.DB.Pool = "from-env", 5*time.Second, []string{"a", "b"}, 6
+		}},
+		{"flag over env and file", map[string]string{"TEST_NAME": "from-env", "TEST_DB_POOL": "6"},
+			[]string{"--config", yamlFile, "--name", "from-flag", "--db.pool", "7"}, func(c *testConfig) {
+				c.Name, c.Timeout, c.Tags, c.DB.Pool = "from-flag", 5*time.Second, []string{"a", "b"}, 7
+			}},
+		{"first env variable set wins", map[string]string{"TEST_NAME": "new", "TEST_LEGACY_NAME": "old"}, nil, func(c *testConfig) {
+			c.Name = "new"
+		}},
+		{"later env variable as fallback", map[string]string{"TEST_LEGACY_NAME": "old"}, nil, func(c *testConfig) {
+			c.Name = "old"
+		}},
+		{"empty env variable is unset", map[string]string{"TEST_NAME": ""}, []string{"--config", yamlFile}, func(c *testConfig) {
+			c.Name, c.Timeout, c.Tags, c.DB.Pool = "from-file", 5*time.Second, []string{"a", "b"}, 4
+		}},
+		{"env list and map syntax", map[string]string{"TEST_TAGS": "x, y,,z", "TEST_LIMITS": "upload=3,list=9"}, nil, func(c *testConfig) {
+			c.Tags, c.Limits = []string{"x", "y", "z"}, map[string]int{"upload": 3, "list": 9}
+		}},
+		{"bool flag without value", nil, []string{"--debug"}, func(c *testConfig) {
+			c.Debug = true
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := defaultTestConfig()
+			if err := load(&got, "test", tt.args, env(tt.env), io.Discard); err != nil {
+				t.Fatalf("load: %v", err)
+			}
+			want := defaultTestConfig()
+			tt.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got  %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string // YAML content; empty for none
+		env     map[string]string
+		args    []string
+		wantErr string
+	}{
+		{"unknown file key", "nmae: typo\n", nil, nil, `unknown key "nmae"`},
+		{"unknown nested key", "db:\n  size: 3\n", nil, nil, `unknown key "db.size"`},
+		{"value for a section", "db: 3\n", nil, nil, "db: want a section"},
+		{"bad file value", "timeout: soon\n", nil, nil, "timeout"},
+		{"bad env value", "", map[string]string{"TEST_TIMEOUT": "soon"}, nil, "config: TEST_TIMEOUT:"},
+		{"bad env map", "", map[string]string{"TEST_LIMITS": "upload"}, nil, "want name=value"},
+		{"bad flag value", "", nil, []string{"--db.pool", "many"}, "config: --db.pool:"},
+		{"stray argument", "", nil, []string{"extra"}, `unexpected argument "extra"`},
+		{"section validation", "", map[string]string{"TEST_DB_POOL": "0"}, nil, "config: db: pool: must be at least 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := tt.args
+			if tt.file != "" {
+				args = append([]string{"--config", writeFile(t, "config.yaml", tt.file)}, args...)
+			}
+			cfg := defaultTestConfig()
+			err := load(&cfg, "test", args, env(tt.env), io.Discard)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadUnsupportedFileType(t *testing.T) {
+	cfg := defaultTestConfig()
+	path := writeFile(t, "config.json", "{}")
+	err := load(&cfg, "test", []string{"--config", path}, env(nil), io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "unsupported file type") {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+// printed returns the --print-config output for the environment vars.
+// Validation is made to fail so that load returns instead of exiting.
+func printed(t *testing.T, cfg *testConfig, vars map[string]string) string {
+	t.Helper()
+	vars["TEST_DB_POOL"] = "0"
+	var b strings.Builder
+	err := load(cfg, "test", []string{"--print-config"}, env(vars), &b)
+	if err == nil || !strings.Contains(err.Error(), "pool") {
+		t.Fatalf("err = %v, want the forced validation error", err)
+	}
+	return b.String()
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	cfg := defaultTestConfig()
+	out := printed(t, &cfg, map[string]string{
+		"TEST_DB_URL": "postgres://app:hunter2@db/app",
+		"TEST_NAME":   "svc",
+	})
+
+	tests := []struct {
+		name string
+		want string // a line of the output
+		not  string // text that must not appear anywhere
+	}{
+		{"set secret is redacted", "url: <redacted> # env TEST_DB_URL", "hunter2"},
+		{"unset secret stays empty", `password: "" # default`, ""},
+		{"plain value is shown", "name: svc # env TEST_NAME", ""},
+		{"duration in Go syntax", "timeout: 1s # default", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !strings.Contains(out, tt.want) {
+				t.Errorf("output lacks %q:\n%s", tt.want, out)
+			}
+			if tt.not != "" && strings.Contains(out, tt.not) {
+				t.Errorf("output contains %q:\n%s", tt.not, out)
+			}
+		})
+	}
+	if cfg.DB.URL != "postgres://app:hunter2@db/app" {
+		t.Errorf("printing changed the secret to %q", cfg.DB.URL)
+	}
+}
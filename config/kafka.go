@@ -0,0 +1,64 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Initial offsets a new consumer group may start from.
+const (
+	OffsetNewest = "newest"
+	OffsetOldest = "oldest"
+)
+
+// Kafka is the Kafka section shared by the grpc-service, which publishes
+// document-upload events, and the consumer, which processes them.
+type Kafka struct {
+	Brokers []string `key:"brokers" env:"KAFKA_BROKERS" usage:"Kafka broker addresses"`
+	Topic   string   `key:"topic" env:"KAFKA_TOPIC" usage:"topic carrying document-upload events"`
+	GroupID string   `key:"group_id" env:"KAFKA_GROUP_ID,KAFKA_CONSUMER_GROUP" usage:"consumer group that processes uploads"`
+	// DeadLetterTopic receives the events the consumer could not process,
+	// which operators inspect and replay through the grpc-service.
+	DeadLetterTopic string `key:"dead_letter_topic" env:"KAFKA_DEAD_LETTER_TOPIC" usage:"topic for events the consumer could not process; empty disables dead-lettering"`
+
+	// The consumer's connection retries and starting offset, which the
+	// grpc-service also measures lag from.
+	ConnectAttempts int           `key:"connect_attempts" env:"KAFKA_CONNECT_ATTEMPTS" usage:"attempts to join the consumer group at startup"`
+	ConnectBackoff  time.Duration `key:"connect_backoff" env:"KAFKA_CONNECT_BACKOFF" usage:"pause between connection attempts"`
+	InitialOffset   string        `key:"initial_offset" env:"KAFKA_INITIAL_OFFSET" usage:"where a new consumer group starts, and lag is measured from: newest or oldest"`
+}
+
+// DefaultKafka returns the settings used by docker-compose.
+func DefaultKafka() Kafka {
+	return Kafka{
+		Brokers:         []string{"kafka:9092"},
+		Topic:           "document-uploads",
+		GroupID:         "pdf-extractor-consumer",
+		DeadLetterTopic: "document-uploads.dlq",
+		ConnectAttempts: 10,
+		ConnectBackoff:  5 * time.Second,
+		InitialOffset:   OffsetNewest,
+	}
+}
+
+// Validate implements Validator.
+func (k *Kafka) Validate() error {
+	switch {
+	case len(k.Brokers) == 0:
+		return errors.New("brokers: at least one broker is required")
+	case k.Topic == "":
+		return errors.New("topic: must not be empty")
+	case k.GroupID == "":
+		return errors.New("group_id: must not be empty")
+	case k.DeadLetterTopic == k.Topic:
+		return fmt.Errorf("dead_letter_topic: %q is the uploads topic", k.DeadLetterTopic)
+	case k.ConnectAttempts < 1:
+		return fmt.Errorf("connect_attempts: %d, want at least 1", k.ConnectAttempts)
+	case k.ConnectBackoff < 0:
+		return fmt.Errorf("connect_backoff: %s is negative", k.ConnectBackoff)
+	case k.InitialOffset != OffsetNewest && k.InitialOffset != OffsetOldest:
+		return fmt.Errorf("initial_offset: %q, want %q or %q", k.InitialOffset, OffsetNewest, OffsetOldest)
+	}
+	return nil
+}
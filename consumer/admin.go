@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/IBM/sarama"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/health"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/logging"
+)
+
+// kafkaProbe checks the Kafka client once main has connected it.
+type kafkaProbe struct {
+	topic  string
+	client atomic.Value // sarama.Client
+}
+
+func (p *kafkaProbe) check(ctx context.Context) error {
+	client, _ := p.client.Load().(sarama.Client)
+	if client == nil {
+		return errors.New("not connected to Kafka yet")
+	}
+	if err := client.RefreshMetadata(p.topic); err != nil {
+		return fmt.Errorf("refresh metadata: %w", err)
+	}
+	return nil
+}
+
+// newAdminServer returns the consumer's admin HTTP server, serving
+// /livez, /readyz, /metrics, /log-level, and /status, /pause and /resume
+// from track. Readiness covers Kafka and both upstreams, which are probed
+// with client. When cfg.AdminToken is set, /log-level, /status, /pause and
+// /resume require it; probes and metrics stay open.
+func newAdminServer(cfg Config, kafka *kafkaProbe, client *http.Client, level *slog.LevelVar, track *tracker) *http.Server {
+	checks := health.NewChecker(cfg.HealthTimeout)
+	checks.Add("kafka", kafka.check)
+	checks.Add("nlp_service", health.HTTPCheck(client, strings.TrimSuffix(cfg.NLP.URL, "/")+"/health"))
+	checks.Add("grpc_service", health.HTTPCheck(client, strings.TrimSuffix(cfg.GRPCService.URL, "/")+"/livez"))
+
+	mux := http.NewServeMux()
+	health.Register(mux, checks)
+	mux.Handle("GET /metrics", promhttp.Handler())
+	mux.Handle("/log-level", requireToken(cfg.AdminToken, logging.LevelHandler(level)))
+
+	// GET /status — assigned partitions with offsets and lag, the messages in
+	// flight and recent errors
+	// POST /pause, POST /resume — stop and restart consumption, e.g. during
+	// NLP maintenance; messages in flight finish
+	mux.Handle("GET /status", requireToken(cfg.AdminToken, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeStatus(w, cfg, track)
+	})))
+	mux.Handle("POST /pause", requireToken(cfg.AdminToken, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if track.pause() {
+			slog.Warn("consumption paused", "remote_addr", r.RemoteAddr)
+		}
+		writeStatus(w, cfg, track)
+	})))
+	mux.Handle("POST /resume", requireToken(cfg.AdminToken, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if track.resume() {
+			slog.Info("consumption resumed", "remote_addr", r.RemoteAddr)
+		}
+		writeStatus(w, cfg, track)
+	})))
+	return &http.Server{Addr: net.JoinHostPort(cfg.AdminHost, cfg.AdminPort), Handler: mux}
+}
+
+// requireToken refuses requests to next that do not carry
+// "Authorization: Bearer <token>". An empty token lets every request
+// through; Config.Validate only allows that on a loopback address.
+func requireToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	want := sha256.Sum256([]byte(token))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, got, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		sum := sha256.Sum256([]byte(strings.TrimSpace(got)))
+		if !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare(sum[:], want[:]) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="consumer-admin"`)
+			http.Error(w, "admin token required", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// writeStatus reports track's state as JSON.
+func writeStatus(w http.ResponseWriter, cfg Config, track *tracker) {
+	s := track.status()
+	s.WorkerID, s.Topic, s.Group = cfg.WorkerID, cfg.Kafka.Topic, cfg.Kafka.GroupID
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(s); err != nil {
+		slog.Error("admin: encode status", "error", err)
+	}
+}
+
+// serveAdmin runs srv until it is shut down.
+func serveAdmin(srv *http.Server) {
+	slog.Info("admin server listening", "addr", srv.Addr)
+	if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("admin server failed", "error", err)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireToken(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	tests := []struct {
+		name          string
+		token, header string
+		want          int
+	}{
+		{"no token configured", "", "", http.StatusOK},
+		{"missing header", "s3cret", "", http.StatusUnauthorized},
+		{"right token", "s3cret", "Bearer s3cret", http.StatusOK},
+		{"scheme is case-insensitive", "s3cret", "bearer s3cret", http.StatusOK},
+		{"wrong token", "s3cret", "Bearer guess", http.StatusUnauthorized},
+		{"wrong scheme", "s3cret", "Basic s3cret", http.StatusUnauthorized},
+		{"token prefix", "s3cret", "Bearer s3c", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/status", nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			requireToken(tt.token, ok).ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+			if w.Code == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Error("401 without WWW-Authenticate")
+			}
+		})
+	}
+}
+
+func TestAdminPauseResume(t *testing.T) {
+	cfg := Config{AdminToken: "s3cret", WorkerID: "w1"}
+	track := newTracker()
+	h := newAdminServer(cfg, &kafkaProbe{}, http.DefaultClient, new(slog.LevelVar), track).Handler
+
+	post := func(path, token string) (int, Status) {
+		t.Helper()
+		r := httptest.NewRequest(http.MethodPost, path, nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		var st Status
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&st); err != nil {
+				t.Fatalf("%s: decode: %v", path, err)
+			}
+		}
+		return w.Code, st
+	}
+
+	if code, _ := post("/pause", ""); code != http.StatusUnauthorized {
+		t.Fatalf("pause without token: %d", code)
+	}
+	if track.status().Paused {
+		t.Fatal("paused by an unauthorized request")
+	}
+	code, st := post("/pause", "s3cret")
+	if code != http.StatusOK || !st.Paused || st.WorkerID != "w1" {
+		t.Fatalf("pause = %d %+v", code, st)
+	}
+	// Pausing twice is harmless.
+	if code, st = post("/pause", "s3cret"); code != http.StatusOK || !st.Paused {
+		t.Fatalf("second pause = %d %+v", code, st)
+	}
+	if code, st = post("/resume", "s3cret"); code != http.StatusOK || st.Paused {
+		t.Fatalf("resume = %d %+v", code, st)
+	}
+}
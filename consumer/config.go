@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/config"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/logging"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/tracing"
+)
+
+// Config is the consumer configuration. See package config for how it is
+// loaded; defaultConfig holds the defaults.
+type Config struct {
+	ShutdownTimeout time.Duration `key:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" usage:"how long messages in flight may take to finish after SIGTERM"`
+	AdminPort       string        `key:"admin_port" env:"ADMIN_PORT" usage:"port of the admin HTTP server (health probes, metrics, status, pause and resume)"`
+	AdminHost       string        `key:"admin_host" env:"ADMIN_HOST" usage:"address the admin HTTP server listens on; empty or 0.0.0.0 for every interface, which requires admin_token"`
+	AdminToken      string        `key:"admin_token" env:"ADMIN_TOKEN" secret:"true" usage:"bearer token required by /status, /pause, /resume and /log-level"`
+	HealthTimeout   time.Duration `key:"health_timeout" env:"HEALTH_CHECK_TIMEOUT" usage:"time each /readyz dependency check may take"`
+	WorkerID        string        `key:"worker_id" env:"WORKER_ID" usage:"name of this consumer on document timelines (default the hostname)"`
+
+	Kafka       config.Kafka      `key:"kafka"`
+	NLP         NLPConfig         `key:"nlp"`
+	GRPCService GRPCServiceConfig `key:"grpc_service"`
+	UpstreamTLS UpstreamTLSConfig `key:"upstream_tls"`
+	Tracing     tracing.Config    `key:"tracing"`
+	Logging     logging.Config    `key:"logging"`
+}
+
+// NLPConfig locates the NLP service and bounds retries of /extract.
+type NLPConfig struct {
+	URL          string        `key:"url" env:"NLP_SERVICE_URL" usage:"base URL of the NLP extraction service"`
+	Attempts     int           `key:"attempts" env:"NLP_ATTEMPTS" usage:"calls made per document before giving up"`
+	RetryBackoff time.Duration `key:"retry_backoff" env:"NLP_RETRY_BACKOFF" usage:"pause before the second attempt, growing linearly after it"`
+}
+
+// GRPCServiceConfig locates the grpc-service result and progress callbacks.
+type GRPCServiceConfig struct {
+	URL             string `key:"url" env:"GRPC_SERVICE_URL" usage:"base URL of the grpc-service REST API"`
+	CallbackSecret  string `key:"callback_secret" env:"CALLBACK_HMAC_SECRET" secret:"true" usage:"shared secret for signing result callbacks"`
+	ProgressUpdates bool   `key:"progress_updates" env:"PROGRESS_UPDATES" usage:"report each NLP attempt as progress, not only the pickup"`
+}
+
+// UpstreamTLSConfig is used for HTTPS calls to both upstreams.
+type UpstreamTLSConfig struct {
+	CAFile     string `key:"ca_file" env:"UPSTREAM_TLS_CA_FILE" usage:"CAs trusted for upstream certificates (default system roots)"`
+	CertFile   string `key:"cert_file" env:"UPSTREAM_TLS_CERT_FILE" usage:"client certificate for mutual TLS"`
+	KeyFile    string `key:"key_file" env:"UPSTREAM_TLS_KEY_FILE" usage:"client key for mutual TLS"`
+	ServerName string `key:"server_name" env:"UPSTREAM_TLS_SERVER_NAME" usage:"name verified in upstream certificates (default the URL host)"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		ShutdownTimeout: 30 * time.Second,
+		AdminPort:       "8081",
+		AdminHost:       "127.0.0.1",
+		HealthTimeout:   2 * time.Second,
+		WorkerID:        defaultWorkerID(),
+		Kafka:           config.DefaultKafka(),
+		NLP: NLPConfig{
+			URL:          "http://nlp-service:8000",
+			Attempts:     3,
+			RetryBackoff: time.Second,
+		},
+		GRPCService: GRPCServiceConfig{URL: "http://grpc-service:8080", ProgressUpdates: true},
+		Tracing:     tracing.DefaultConfig(),
+		Logging:     logging.DefaultConfig(),
+	}
+}
+
+// defaultWorkerID is the hostname, which is the container ID under Docker.
+func defaultWorkerID() string {
+	if host, err := os.Hostname(); err == nil && host != "" {
+		return host
+	}
+	return "consumer"
+}
+
+// Validate implements config.Validator.
+func (c *Config) Validate() error {
+	if c.ShutdownTimeout <= 0 {
+		return fmt.Errorf("shutdown_timeout: %s, want a positive duration", c.ShutdownTimeout)
+	}
+	if n, err := strconv.Atoi(c.AdminPort); err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("admin_port: %q is not a port", c.AdminPort)
+	}
+	if !isLoopback(c.AdminHost) && c.AdminToken == "" {
+		return fmt.Errorf("admin_host: %q is reachable from other hosts; set admin_token (ADMIN_TOKEN) to expose pause and resume", c.AdminHost)
+	}
+	if c.HealthTimeout <= 0 {
+		return fmt.Errorf("health_timeout: %s, want a positive duration", c.HealthTimeout)
+	}
+	if c.WorkerID == "" {
+		return errors.New("worker_id: must not be empty")
+	}
+	return nil
+}
+
+// isLoopback reports whether host only accepts connections from this host.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Validate implements config.Validator.
+func (c *NLPConfig) Validate() error {
+	switch {
+	case c.URL == "":
+		return errors.New("url: must not be empty")
+	case c.Attempts < 1:
+		return fmt.Errorf("attempts: %d, want at least 1", c.Attempts)
+	case c.RetryBackoff < 0:
+		return fmt.Errorf("retry_backoff: %s is negative", c.RetryBackoff)
+	}
+	return nil
+}
+
+// Validate implements config.Validator.
+func (c *GRPCServiceConfig) Validate() error {
+	if c.URL == "" {
+		return errors.New("url: must not be empty")
+	}
+	return nil
+}
+
+// Validate implements config.Validator.
+func (c *UpstreamTLSConfig) Validate() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("cert_file and key_file must be set together")
+	}
+	return nil
+}
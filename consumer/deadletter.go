@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Headers added to a dead-lettered message, beside the original message's
+// own. The grpc-service reads them back when listing dead letters, and drops
+// them again when replaying.
+const (
+	deadLetterHeaderPrefix    = "dead_letter."
+	headerDeadLetterReason    = "dead_letter.reason"
+	headerDeadLetterError     = "dead_letter.error"
+	headerDeadLetterTopic     = "dead_letter.topic"
+	headerDeadLetterPartition = "dead_letter.partition"
+	headerDeadLetterOffset    = "dead_letter.offset"
+	headerDeadLetterAt        = "dead_letter.at"
+	headerDeadLetterWorkerID  = "dead_letter.worker_id"
+)
+
+// deadLetterWriter copies messages the consumer could not process to the
+// dead-letter topic, unchanged, so that operators can inspect and replay
+// them. The reason is one of the error kinds kept for GET /status.
+type deadLetterWriter struct {
+	producer sarama.SyncProducer
+	topic    string
+	workerID string
+}
+
+// write dead-letters msg. A nil writer drops it.
+func (d *deadLetterWriter) write(ctx context.Context, msg *sarama.ConsumerMessage, reason string, cause error) error {
+	if d == nil {
+		return nil
+	}
+	headers := make([]sarama.RecordHeader, 0, len(msg.Headers)+7)
+	for _, h := range msg.Headers {
+		// A replayed message that fails again carries its first failure's
+		// headers; only the latest is kept.
+		if h != nil && !strings.HasPrefix(string(h.Key), deadLetterHeaderPrefix) {
+			headers = append(headers, *h)
+		}
+	}
+	for _, h := range [][2]string{
+		{headerDeadLetterReason, reason},
+		{headerDeadLetterError, cause.Error()},
+		{headerDeadLetterTopic, msg.Topic},
+		{headerDeadLetterPartition, strconv.Itoa(int(msg.Partition))},
+		{headerDeadLetterOffset, strconv.FormatInt(msg.Offset, 10)},
+		{headerDeadLetterAt, time.Now().UTC().Format(time.RFC3339Nano)},
+		{headerDeadLetterWorkerID, d.workerID},
+	} {
+		headers = append(headers, sarama.RecordHeader{Key: []byte(h[0]), Value: []byte(h[1])})
+	}
+
+	out := &sarama.ProducerMessage{Topic: d.topic, Value: sarama.ByteEncoder(msg.Value), Headers: headers}
+	if msg.Key != nil {
+		out.Key = sarama.ByteEncoder(msg.Key)
+	}
+	partition, offset, err := d.producer.SendMessage(out)
+	if err != nil {
+		return fmt.Errorf("dead-letter to %s: %w", d.topic, err)
+	}
+	deadLettersTotal.WithLabelValues(reason).Inc()
+	slog.WarnContext(ctx, "message dead-lettered", "reason", reason,
+		"dead_letter_topic", d.topic, "dead_letter_partition", partition, "dead_letter_offset", offset)
+	return nil
+}
+
+// Close shuts down the writer's producer. A nil writer has nothing to close.
+func (d *deadLetterWriter) Close() error {
+	if d == nil {
+		return nil
+	}
+	return d.producer.Close()
+}
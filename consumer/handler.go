@@ -2,92 +2,318 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
+	"strconv"
+	"time"
 
 	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/logging"
 )
 
+var tracer = otel.Tracer("github.com/ryan-dayrit/nlp-pdf-extractor/consumer")
+
 // KafkaMessage represents the message format from the document-uploads topic.
 type KafkaMessage struct {
-	DocumentID   string   `json:"document_id"`
-	Filename     string   `json:"filename"`
-	PDFDataB64   string   `json:"pdf_data_base64"`
-	DataPoints   []string `json:"data_points"`
+	DocumentID string   `json:"document_id"`
+	TenantID   string   `json:"tenant_id"`
+	Filename   string   `json:"filename"`
+	PDFDataB64 string   `json:"pdf_data_base64"`
+	DataPoints []string `json:"data_points"`
 }
 
-// DataPointsPayload is the body sent to the gRPC service.
+// DataPointsPayload is the body sent to the gRPC service. TenantID echoes the
+// event's tenant so results can only land on that tenant's document; Pages is
+// the page count the NLP service reported, metered for usage. WorkerID and
+// Events feed the document's timeline.
 type DataPointsPayload struct {
-	Results map[string]string `json:"results"`
+	TenantID string                     `json:"tenant_id,omitempty"`
+	Results  map[string]string          `json:"results"`
+	Details  map[string]DataPointResult `json:"details,omitempty"`
+	Pages    int                        `json:"pages,omitempty"`
+	WorkerID string                     `json:"worker_id,omitempty"`
+	Events   []TimelineEvent            `json:"events,omitempty"`
+	// Error is set when the NLP service failed, so that the document is
+	// marked failed even when it requested no data points.
+	Error string `json:"error,omitempty"`
+}
+
+// consumerHeaderCarrier lets a propagator read Kafka record headers.
+type consumerHeaderCarrier []*sarama.RecordHeader
+
+func (c consumerHeaderCarrier) Get(key string) string {
+	for _, h := range c {
+		if h != nil && string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Set is unused: records are dead-lettered with their headers as received.
+func (c consumerHeaderCarrier) Set(string, string) {}
+
+func (c consumerHeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for _, h := range c {
+		if h != nil {
+			keys = append(keys, string(h.Key))
+		}
+	}
+	return keys
 }
 
 // ConsumerGroupHandler implements sarama.ConsumerGroupHandler.
-type ConsumerGroupHandler struct{}
+//
+// Work is the context documents are processed under. It outlives the
+// consumer session so that a message in flight at shutdown can finish;
+// cancelling it abandons that message without marking it, so Kafka
+// redelivers it. NLP and GRPCService locate the upstreams, WorkerID names
+// this consumer on document timelines, and Tracker keeps its state for the
+// admin server and holds messages back while paused. DeadLetters receives
+// the messages that could not be processed; when nil they are dropped.
+type ConsumerGroupHandler struct {
+	Work        context.Context
+	NLP         NLPConfig
+	GRPCService GRPCServiceConfig
+	WorkerID    string
+	Tracker     *tracker
+	DeadLetters *deadLetterWriter
+}
 
-func (h *ConsumerGroupHandler) Setup(_ sarama.ConsumerGroupSession) error {
-	log.Println("Consumer group session setup")
+func (h *ConsumerGroupHandler) Setup(session sarama.ConsumerGroupSession) error {
+	slog.Info("consumer group session setup", "member_id", session.MemberID(), "generation", session.GenerationID())
+	h.Tracker.sessionStarted(session)
 	return nil
 }
 
 func (h *ConsumerGroupHandler) Cleanup(_ sarama.ConsumerGroupSession) error {
-	log.Println("Consumer group session cleanup")
+	slog.Info("consumer group session cleanup")
+	h.Tracker.sessionEnded()
+	// Partitions may be reassigned; the next session reports its own.
+	consumerLag.Reset()
 	return nil
 }
 
+// ConsumeClaim processes messages one at a time until the session ends. Once
+// it does, no further message is started. While consumption is paused no
+// message is started either, including those fetched before the pause.
 func (h *ConsumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("Recovered from panic in ConsumeClaim: %v", r)
+			slog.Error("recovered from panic in ConsumeClaim", "panic", r)
 		}
 	}()
+	h.Tracker.claimed(claim)
 
-	for msg := range claim.Messages() {
-		log.Printf("Received message: partition=%d offset=%d", msg.Partition, msg.Offset)
-
-		var km KafkaMessage
-		if err := json.Unmarshal(msg.Value, &km); err != nil {
-			log.Printf("Failed to unmarshal message: %v", err)
-			session.MarkMessage(msg, "")
-			continue
+	for {
+		select {
+		case <-session.Context().Done():
+			return nil
+		case msg, ok := <-claim.Messages():
+			if !ok {
+				return nil
+			}
+			if !h.waitWhilePaused(session) {
+				// Unmarked, so the message is redelivered.
+				return nil
+			}
+			if h.process(msg) {
+				session.MarkMessage(msg, "")
+				h.Tracker.processed(msg.Partition, msg.Offset)
+				recordLag(msg.Partition, claim.HighWaterMarkOffset(), msg.Offset)
+			} else {
+				messagesTotal.WithLabelValues(outcomeHandedBack).Inc()
+				slog.Warn("handing back message unprocessed", "partition", msg.Partition, "offset", msg.Offset)
+			}
 		}
+	}
+}
+
+// waitWhilePaused blocks while consumption is paused. It returns false if
+// the session ends first.
+func (h *ConsumerGroupHandler) waitWhilePaused(session sarama.ConsumerGroupSession) bool {
+	resumed := h.Tracker.resumedCh()
+	if resumed == nil {
+		return true
+	}
+	select {
+	case <-session.Context().Done():
+		return false
+	case <-resumed:
+		return true
+	}
+}
 
-		log.Printf("Processing document_id=%s filename=%s", km.DocumentID, km.Filename)
+// process extracts and reports one message. It returns false when the work
+// was abandoned because h.Work was cancelled, leaving the message unmarked.
+// Its span continues the trace the grpc-service put in the record headers,
+// and its logs carry the request ID found there.
+func (h *ConsumerGroupHandler) process(msg *sarama.ConsumerMessage) bool {
+	headers := consumerHeaderCarrier(msg.Headers)
+	requestID := headers.Get(logging.RequestIDKey)
+	if !logging.ValidRequestID(requestID) {
+		requestID = logging.NewRequestID()
+	}
+	ctx := logging.WithRequestID(h.Work, requestID)
+	ctx = logging.With(ctx, "partition", msg.Partition, "offset", msg.Offset)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, headers)
+	ctx, span := tracer.Start(ctx, msg.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.source.name", msg.Topic),
+			attribute.Int("messaging.kafka.source.partition", int(msg.Partition)),
+			attribute.Int64("messaging.kafka.message.offset", msg.Offset),
+		))
+	defer span.End()
+	slog.DebugContext(ctx, "received message")
 
-		results, err := callNLPService(km.PDFDataB64, km.DataPoints)
-		if err != nil {
-			log.Printf("NLP service error for document_id=%s: %v — marking message consumed", km.DocumentID, err)
-			session.MarkMessage(msg, "")
-			continue
-		}
+	var km KafkaMessage
+	if err := json.Unmarshal(msg.Value, &km); err != nil {
+		slog.ErrorContext(ctx, "failed to unmarshal message", "error", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "invalid message")
+		messagesTotal.WithLabelValues(outcomeInvalid).Inc()
+		h.Tracker.fail(errorInvalidMessage, "", fmt.Errorf("partition %d offset %d: %w", msg.Partition, msg.Offset, err))
+		h.deadLetter(ctx, msg, "", errorInvalidMessage, err)
+		return true
+	}
+	span.SetAttributes(attribute.String("document_id", km.DocumentID), attribute.String("tenant", km.TenantID))
+	ctx = logging.With(ctx, "document_id", km.DocumentID, "tenant", km.TenantID)
+	start := time.Now()
+	h.Tracker.begin(InFlight{
+		DocumentID: km.DocumentID,
+		TenantID:   km.TenantID,
+		Filename:   km.Filename,
+		Topic:      msg.Topic,
+		Partition:  msg.Partition,
+		Offset:     msg.Offset,
+		RequestID:  requestID,
+		StartedAt:  start.UTC(),
+	})
+	defer h.Tracker.end(msg.Topic, msg.Partition)
+	rep := &reporter{
+		cfg:     h.GRPCService,
+		docID:   km.DocumentID,
+		base:    ProgressPayload{TenantID: km.TenantID, WorkerID: h.WorkerID, StartedAt: start.UTC()},
+		updates: h.GRPCService.ProgressUpdates,
+	}
+	rep.add(eventPickedUp, map[string]string{
+		"partition": strconv.Itoa(int(msg.Partition)),
+		"offset":    strconv.FormatInt(msg.Offset, 10),
+	})
+	rep.stage(ctx, stagePickedUp, "")
 
-		log.Printf("NLP extraction complete for document_id=%s, sending results to gRPC service", km.DocumentID)
+	slog.InfoContext(ctx, "processing document", "filename", km.Filename)
 
-		if err := sendResultsToGRPCService(km.DocumentID, results); err != nil {
-			log.Printf("Failed to send results to gRPC service for document_id=%s: %v", km.DocumentID, err)
-		} else {
-			log.Printf("Successfully updated document_id=%s", km.DocumentID)
+	// failure is why the message is dead-lettered once it is done with.
+	var failure struct {
+		kind string
+		err  error
+	}
+	var nlpErr string
+	nlpResp, err := callNLPService(ctx, h.NLP, km.PDFDataB64, km.DataPoints, rep)
+	if h.Work.Err() != nil {
+		span.SetStatus(codes.Error, "abandoned at shutdown")
+		return false
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "NLP extraction failed; reporting data points as errored", "error", err)
+		nlpFailures.Inc()
+		h.Tracker.fail(errorNLP, km.DocumentID, err)
+		nlpResp = failedResponse(km.DataPoints, err)
+		nlpErr = err.Error()
+		failure.kind, failure.err = errorNLP, err
+	} else {
+		slog.InfoContext(ctx, "NLP extraction complete; sending results to gRPC service")
+	}
+
+	callbacksTotal.Inc()
+	payload := DataPointsPayload{
+		TenantID: km.TenantID,
+		Results:  nlpResp.Results,
+		Details:  nlpResp.Details,
+		Pages:    nlpResp.PageCount,
+		WorkerID: h.WorkerID,
+		Events:   rep.events,
+		Error:    nlpErr,
+	}
+	if err := sendResultsToGRPCService(ctx, h.GRPCService, km.DocumentID, payload); err != nil {
+		if h.Work.Err() != nil {
+			span.SetStatus(codes.Error, "abandoned at shutdown")
+			return false
 		}
+		callbackFailures.Inc()
+		h.Tracker.fail(errorCallback, km.DocumentID, err)
+		span.SetStatus(codes.Error, "results not delivered")
+		slog.ErrorContext(ctx, "failed to send results to gRPC service", "error", err)
+		failure.kind, failure.err = errorCallback, err
+	} else {
+		slog.InfoContext(ctx, "document updated", "duration", time.Since(start))
+	}
+	if failure.err != nil {
+		h.deadLetter(ctx, msg, km.DocumentID, failure.kind, failure.err)
+	}
+	messagesTotal.WithLabelValues(outcomeProcessed).Inc()
+	processingDuration.Observe(time.Since(start).Seconds())
+	return true
+}
 
-		session.MarkMessage(msg, "")
+// deadLetter copies msg to the dead-letter topic. The message is marked
+// done either way, so a failure to write it is only recorded: handing it
+// back would not get it processed again.
+func (h *ConsumerGroupHandler) deadLetter(ctx context.Context, msg *sarama.ConsumerMessage, documentID, kind string, cause error) {
+	if err := h.DeadLetters.write(ctx, msg, kind, cause); err != nil {
+		slog.ErrorContext(ctx, "failed to dead-letter message", "reason", kind, "error", err)
+		h.Tracker.fail(errorDeadLetter, documentID, err)
 	}
+}
 
+func sendResultsToGRPCService(ctx context.Context, cfg GRPCServiceConfig, documentID string, payload DataPointsPayload) (err error) {
+	ctx, span := tracer.Start(ctx, "send results", trace.WithAttributes(attribute.String("document_id", documentID)))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "callback failed")
+		}
+		span.End()
+	}()
+	if err := postCallback(ctx, cfg, "/documents/"+documentID+"/datapoints", payload); err != nil {
+		return err
+	}
+	slog.DebugContext(ctx, "gRPC service accepted results")
 	return nil
 }
 
-func sendResultsToGRPCService(documentID string, results map[string]string) error {
-	grpcServiceURL := getEnv("GRPC_SERVICE_URL", "http://grpc-service:8080")
-	url := fmt.Sprintf("%s/documents/%s/datapoints", grpcServiceURL, documentID)
-
-	payload := DataPointsPayload{Results: results}
+// postCallback sends payload as a signed JSON POST to path on the
+// grpc-service.
+func postCallback(ctx context.Context, cfg GRPCServiceConfig, path string, payload any) error {
 	body, err := json.Marshal(payload)
 	if err != nil {
-		return fmt.Errorf("marshal results: %w", err)
+		return fmt.Errorf("marshal callback: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cfg.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("build request: %w", err)
 	}
+	req.Header.Set("Content-Type", "application/json")
+	setRequestID(req)
+	signRequest(req, body, []byte(cfg.CallbackSecret))
 
-	resp, err := http.Post(url, "application/json", bytes.NewReader(body)) //nolint:noctx
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("POST to gRPC service: %w", err)
 	}
@@ -96,11 +322,29 @@ func sendResultsToGRPCService(documentID string, results map[string]string) erro
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		return fmt.Errorf("gRPC service returned status %d", resp.StatusCode)
 	}
-
-	log.Printf("gRPC service accepted results for document_id=%s (status %d)", documentID, resp.StatusCode)
 	return nil
 }
 
-// getEnvHandler allows handler.go to read env vars without importing os directly
-// (uses the getEnv helper defined in main.go).
-var _ = os.Getenv // ensure os is used
+// setRequestID passes the request ID of req's context on to the upstream.
+func setRequestID(req *http.Request) {
+	if id := logging.RequestID(req.Context()); id != "" {
+		req.Header.Set(logging.RequestIDHeader, id)
+	}
+}
+
+// signRequest adds the X-Callback-Timestamp and X-Callback-Signature headers
+// the grpc-service requires on result callbacks: the hex HMAC-SHA256 of
+// "<unix timestamp>.<METHOD>.<path>." followed by the body. Without a secret
+// the request goes out unsigned and will be rejected.
+func signRequest(req *http.Request, body, secret []byte) {
+	if len(secret) == 0 {
+		slog.WarnContext(req.Context(), "CALLBACK_HMAC_SECRET not set; sending unsigned callback")
+		return
+	}
+	ts := time.Now().Unix()
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%d.%s.%s.", ts, req.Method, req.URL.Path)
+	mac.Write(body)
+	req.Header.Set("X-Callback-Timestamp", strconv.FormatInt(ts, 10))
+	req.Header.Set("X-Callback-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+}
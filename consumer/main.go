@@ -2,61 +2,120 @@ package main
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
-	"strings"
 	"syscall"
 	"time"
 
 	"github.com/IBM/sarama"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/config"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/logging"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/tracing"
 )
 
-func getEnv(key, defaultVal string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
+func main() {
+	cfg := defaultConfig()
+	if err := config.Load(&cfg); err != nil {
+		fatal("config", err)
 	}
-	return defaultVal
-}
+	logLevel, err := logging.Setup("consumer", cfg.Logging)
+	if err != nil {
+		fatal("logging", err)
+	}
+	brokers := cfg.Kafka.Brokers
+	topic := cfg.Kafka.Topic
+	attempts := cfg.Kafka.ConnectAttempts
 
-func main() {
-	brokers := strings.Split(getEnv("KAFKA_BROKERS", "kafka:9092"), ",")
-	groupID := getEnv("KAFKA_GROUP_ID", "pdf-extractor-consumer")
-	topic := "document-uploads"
-
-	config := sarama.NewConfig()
-	config.Version = sarama.V2_1_0_0
-	config.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRoundRobin()}
-	config.Consumer.Offsets.Initial = sarama.OffsetNewest
-
-	var consumerGroup sarama.ConsumerGroup
-	var err error
-	for attempt := 1; attempt <= 10; attempt++ {
-		consumerGroup, err = sarama.NewConsumerGroup(brokers, groupID, config)
+	upstream, err := newUpstreamTLS(cfg.UpstreamTLS)
+	if err != nil {
+		fatal("invalid upstream TLS configuration", err)
+	}
+	if upstream != nil {
+		httpClient = newHTTPClient(upstream)
+		slog.Info("upstream TLS enabled")
+	}
+
+	flushTraces, err := tracing.Setup(context.Background(), "consumer", cfg.Tracing)
+	if err != nil {
+		fatal("tracing", err)
+	}
+	// Upstream calls carry the trace context; health probes stay untraced.
+	probeClient := httpClient
+	httpClient = &http.Client{Transport: otelhttp.NewTransport(probeClient.Transport)}
+
+	// The admin server starts first so liveness holds while Kafka connects.
+	probe := &kafkaProbe{topic: topic}
+	track := newTracker()
+	admin := newAdminServer(cfg, probe, probeClient, logLevel, track)
+	go serveAdmin(admin)
+
+	saramaCfg := sarama.NewConfig()
+	saramaCfg.Version = sarama.V2_1_0_0
+	saramaCfg.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategyRoundRobin()}
+	saramaCfg.Consumer.Offsets.Initial = sarama.OffsetNewest
+	if cfg.Kafka.InitialOffset == config.OffsetOldest {
+		saramaCfg.Consumer.Offsets.Initial = sarama.OffsetOldest
+	}
+	// The dead-letter producer shares the client, and a sync producer
+	// needs successes returned.
+	saramaCfg.Producer.Return.Successes = true
+
+	var client sarama.Client
+	for attempt := 1; attempt <= attempts; attempt++ {
+		client, err = sarama.NewClient(brokers, saramaCfg)
 		if err == nil {
 			break
 		}
-		log.Printf("Failed to connect to Kafka (attempt %d/10): %v", attempt, err)
-		if attempt < 10 {
-			time.Sleep(5 * time.Second)
+		slog.Warn("failed to connect to Kafka", "attempt", attempt, "attempts", attempts, "error", err)
+		if attempt < attempts {
+			time.Sleep(cfg.Kafka.ConnectBackoff)
 		}
 	}
 	if err != nil {
-		log.Fatalf("Failed to create Kafka consumer group after 10 attempts: %v", err)
+		fatal(fmt.Sprintf("failed to connect to Kafka after %d attempts", attempts), err)
+	}
+	consumerGroup, err := sarama.NewConsumerGroupFromClient(cfg.Kafka.GroupID, client)
+	if err != nil {
+		fatal("failed to create Kafka consumer group", err)
 	}
-	defer consumerGroup.Close()
+	probe.client.Store(client)
+	track.setGroup(consumerGroup)
+	slog.Info("connected to Kafka", "brokers", brokers)
 
-	log.Printf("Connected to Kafka brokers: %v", brokers)
+	var deadLetters *deadLetterWriter
+	if dlq := cfg.Kafka.DeadLetterTopic; dlq != "" {
+		producer, err := sarama.NewSyncProducerFromClient(client)
+		if err != nil {
+			fatal("failed to create dead-letter producer", err)
+		}
+		deadLetters = &deadLetterWriter{producer: producer, topic: dlq, workerID: cfg.WorkerID}
+		slog.Info("dead-lettering enabled", "topic", dlq)
+	} else {
+		slog.Warn("no dead-letter topic; messages that cannot be processed are dropped")
+	}
 
-	handler := &ConsumerGroupHandler{}
+	drainTimeout := cfg.ShutdownTimeout
 
+	// ctx ends the consumer session; work bounds the messages in flight.
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	work, abandon := context.WithCancel(context.Background())
+	defer abandon()
+
+	handler := &ConsumerGroupHandler{Work: work, NLP: cfg.NLP, GRPCService: cfg.GRPCService, WorkerID: cfg.WorkerID, Tracker: track, DeadLetters: deadLetters}
 
+	consuming := make(chan struct{})
 	go func() {
+		defer close(consuming)
 		for {
 			if err := consumerGroup.Consume(ctx, []string{topic}, handler); err != nil {
-				log.Printf("Consumer group error: %v", err)
+				slog.Error("consumer group error", "error", err)
+				track.fail(errorConsumerGroup, "", err)
 			}
 			if ctx.Err() != nil {
 				return
@@ -64,11 +123,43 @@ func main() {
 		}
 	}()
 
-	log.Printf("Consumer started. Listening on topic: %s", topic)
+	slog.Info("consumer started", "topic", topic, "group", cfg.Kafka.GroupID)
 
 	sigterm := make(chan os.Signal, 1)
 	signal.Notify(sigterm, syscall.SIGINT, syscall.SIGTERM)
 	<-sigterm
 
-	log.Println("Shutting down consumer...")
+	slog.Info("shutting down consumer: finishing in-flight message", "timeout", drainTimeout)
+	cancel()
+	select {
+	case <-consuming:
+	case <-time.After(drainTimeout):
+		slog.Warn("in-flight message did not finish in time; handing it back", "timeout", drainTimeout)
+		abandon()
+		<-consuming
+	}
+
+	// Closing the group commits the offsets of every message marked done.
+	if err := consumerGroup.Close(); err != nil {
+		slog.Error("closing consumer group", "error", err)
+	}
+	if err := deadLetters.Close(); err != nil {
+		slog.Error("closing dead-letter producer", "error", err)
+	}
+	if err := client.Close(); err != nil {
+		slog.Error("closing Kafka client", "error", err)
+	}
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+	admin.Shutdown(shutdownCtx)
+	if err := flushTraces(shutdownCtx); err != nil {
+		slog.Error("flushing traces", "error", err)
+	}
+	slog.Info("consumer stopped")
+}
+
+// fatal logs err and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
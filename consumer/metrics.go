@@ -0,0 +1,91 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const metricsNamespace = "pdf_extractor_consumer"
+
+// Message outcomes recorded by messagesTotal.
+const (
+	outcomeProcessed  = "processed"
+	outcomeInvalid    = "invalid"
+	outcomeHandedBack = "handed_back"
+)
+
+var (
+	messagesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "messages_total",
+		Help:      "Kafka messages by outcome: processed, invalid or handed_back.",
+	}, []string{"outcome"})
+	processingDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "processing_duration_seconds",
+		Help:      "Time from picking up a message to reporting its results.",
+		Buckets:   prometheus.ExponentialBuckets(0.25, 2, 12),
+	})
+
+	nlpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "nlp_request_duration_seconds",
+		Help:      "Latency of each NLP /extract attempt by outcome (ok or error).",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+	}, []string{"outcome"})
+	nlpRetries = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "nlp_retries_total",
+		Help:      "NLP /extract attempts after the first for a document.",
+	})
+	nlpFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "nlp_failures_total",
+		Help:      "Documents whose NLP extraction failed after every attempt.",
+	})
+
+	callbacksTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "callbacks_total",
+		Help:      "Result callbacks sent to the grpc-service.",
+	})
+	callbackFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "callback_failures_total",
+		Help:      "Result callbacks that failed or were refused.",
+	})
+
+	deadLettersTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "dead_letters_total",
+		Help:      "Messages copied to the dead-letter topic, by reason: invalid_message, nlp or callback.",
+	}, []string{"reason"})
+
+	progressReports = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "progress_reports_total",
+		Help:      "Progress callbacks sent to the grpc-service.",
+	})
+	progressFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "progress_failures_total",
+		Help:      "Progress callbacks that failed or were refused.",
+	})
+
+	consumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "lag",
+		Help:      "Messages behind the partition's high-water mark after the last one processed.",
+	}, []string{"partition"})
+)
+
+// recordLag updates the lag gauge for partition after offset was handled.
+func recordLag(partition int32, highWaterMark, offset int64) {
+	lag := highWaterMark - offset - 1
+	if lag < 0 {
+		lag = 0
+	}
+	consumerLag.WithLabelValues(strconv.Itoa(int(partition))).Set(float64(lag))
+}
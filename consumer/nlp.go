@@ -2,12 +2,17 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // nlpRequest is the body sent to the NLP service.
@@ -16,16 +21,59 @@ type nlpRequest struct {
 	DataPoints []string `json:"data_points"`
 }
 
+// BoundingBox is the rectangle, in PDF points, enclosing an extracted value.
+type BoundingBox struct {
+	X0 float64 `json:"x0"`
+	Y0 float64 `json:"y0"`
+	X1 float64 `json:"x1"`
+	Y1 float64 `json:"y1"`
+}
+
+// Candidate is one possible value for a data point together with its
+// confidence and the location of the evidence in the source PDF.
+type Candidate struct {
+	Value       string       `json:"value"`
+	Confidence  float64      `json:"confidence"`
+	Page        int32        `json:"page"`
+	BBox        *BoundingBox `json:"bbox,omitempty"`
+	StartOffset int32        `json:"start_offset"`
+	EndOffset   int32        `json:"end_offset"`
+	Snippet     string       `json:"snippet"`
+}
+
+// statusError marks a data point whose extraction failed outright.
+const statusError = "error"
+
+// DataPointResult is the chosen value for a data point plus every ranked
+// candidate the NLP service found, best first. Status is one of found,
+// not_found, ambiguous, error or skipped; Reason explains anything but found.
+type DataPointResult struct {
+	Candidate
+	Candidates []Candidate `json:"candidates"`
+	Status     string      `json:"status"`
+	Reason     string      `json:"reason,omitempty"`
+}
+
 // nlpResponse is the response from the NLP service.
 type nlpResponse struct {
-	Results map[string]string `json:"results"`
+	Results   map[string]string          `json:"results"`
+	Details   map[string]DataPointResult `json:"details"`
+	PageCount int                        `json:"page_count"`
 }
 
 // callNLPService posts the PDF data and data points to the NLP service,
-// retrying up to 3 times on failure.
-func callNLPService(pdfBase64 string, dataPoints []string) (map[string]string, error) {
-	nlpServiceURL := getEnv("NLP_SERVICE_URL", "http://nlp-service:8000")
-	url := fmt.Sprintf("%s/extract", nlpServiceURL)
+// making up to cfg.Attempts calls, each reported through rep. It gives up
+// early when ctx is done.
+func callNLPService(ctx context.Context, cfg NLPConfig, pdfBase64 string, dataPoints []string, rep *reporter) (_ *nlpResponse, err error) {
+	ctx, span := tracer.Start(ctx, "nlp extract", trace.WithAttributes(attribute.Int("data_points", len(dataPoints))))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "extraction failed")
+		}
+		span.End()
+	}()
+	url := fmt.Sprintf("%s/extract", cfg.URL)
 
 	reqBody, err := json.Marshal(nlpRequest{
 		PDFB64:     pdfBase64,
@@ -36,53 +84,87 @@ func callNLPService(pdfBase64 string, dataPoints []string) (map[string]string, e
 	}
 
 	var lastErr error
-	for attempt := 1; attempt <= 3; attempt++ {
-		log.Printf("Calling NLP service (attempt %d/3): %s", attempt, url)
+	for attempt := 1; attempt <= cfg.Attempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(attempt-1) * cfg.RetryBackoff):
+			}
+			nlpRetries.Inc()
+		}
+		span.AddEvent("attempt", trace.WithAttributes(attribute.Int("attempt", attempt)))
+		rep.stage(ctx, stageExtracting, fmt.Sprintf("NLP attempt %d of %d", attempt, cfg.Attempts))
+		slog.DebugContext(ctx, "calling NLP service", "attempt", attempt, "attempts", cfg.Attempts, "url", url)
 
-		resp, err := http.Post(url, "application/json", bytes.NewReader(reqBody)) //nolint:noctx
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(reqBody))
 		if err != nil {
-			lastErr = fmt.Errorf("attempt %d: POST to NLP service: %w", attempt, err)
-			log.Printf("NLP service call failed: %v", lastErr)
-			if attempt < 3 {
-				time.Sleep(time.Duration(attempt) * time.Second)
+			return nil, fmt.Errorf("build NLP request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		setRequestID(req)
+		start := time.Now()
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
 			}
+			nlpDuration.WithLabelValues("error").Observe(time.Since(start).Seconds())
+			rep.nlpAttempt(attempt, time.Since(start), err)
+			lastErr = fmt.Errorf("attempt %d: POST to NLP service: %w", attempt, err)
+			slog.WarnContext(ctx, "NLP service call failed", "attempt", attempt, "error", err)
 			continue
 		}
 
 		body, readErr := io.ReadAll(resp.Body)
 		resp.Body.Close()
+		outcome := "ok"
+		if readErr != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			outcome = "error"
+		}
+		nlpDuration.WithLabelValues(outcome).Observe(time.Since(start).Seconds())
 
 		if readErr != nil {
+			rep.nlpAttempt(attempt, time.Since(start), readErr)
 			lastErr = fmt.Errorf("attempt %d: read NLP response body: %w", attempt, readErr)
-			log.Printf("NLP response read failed: %v", lastErr)
-			if attempt < 3 {
-				time.Sleep(time.Duration(attempt) * time.Second)
-			}
+			slog.WarnContext(ctx, "NLP response read failed", "attempt", attempt, "error", readErr)
 			continue
 		}
 
 		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			rep.nlpAttempt(attempt, time.Since(start), fmt.Errorf("status %d", resp.StatusCode))
 			lastErr = fmt.Errorf("attempt %d: NLP service returned status %d: %s", attempt, resp.StatusCode, body)
-			log.Printf("NLP service error: %v", lastErr)
-			if attempt < 3 {
-				time.Sleep(time.Duration(attempt) * time.Second)
-			}
+			slog.WarnContext(ctx, "NLP service error", "attempt", attempt, "status", resp.StatusCode, "body", string(body))
 			continue
 		}
 
 		var nlpResp nlpResponse
 		if err := json.Unmarshal(body, &nlpResp); err != nil {
+			rep.nlpAttempt(attempt, time.Since(start), err)
 			lastErr = fmt.Errorf("attempt %d: unmarshal NLP response: %w", attempt, err)
-			log.Printf("NLP response unmarshal failed: %v", lastErr)
-			if attempt < 3 {
-				time.Sleep(time.Duration(attempt) * time.Second)
-			}
+			slog.WarnContext(ctx, "NLP response unmarshal failed", "attempt", attempt, "error", err)
 			continue
 		}
 
-		log.Printf("NLP service returned %d results", len(nlpResp.Results))
-		return nlpResp.Results, nil
+		rep.nlpAttempt(attempt, time.Since(start), nil)
+		slog.DebugContext(ctx, "NLP service returned results", "attempt", attempt, "results", len(nlpResp.Results))
+		return &nlpResp, nil
 	}
 
-	return nil, fmt.Errorf("NLP service failed after 3 attempts: %w", lastErr)
+	return nil, fmt.Errorf("NLP service failed after %d attempts: %w", cfg.Attempts, lastErr)
+}
+
+// failedResponse builds the response reported when the NLP service could not
+// be reached or rejected the document: every data point gets an error status
+// carrying err as its reason.
+func failedResponse(dataPoints []string, err error) *nlpResponse {
+	resp := &nlpResponse{
+		Results: make(map[string]string, len(dataPoints)),
+		Details: make(map[string]DataPointResult, len(dataPoints)),
+	}
+	for _, dp := range dataPoints {
+		resp.Results[dp] = ""
+		resp.Details[dp] = DataPointResult{Status: statusError, Reason: err.Error()}
+	}
+	return resp
 }
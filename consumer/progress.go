@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Processing stages reported to the grpc-service.
+const (
+	stagePickedUp   = "picked_up"
+	stageExtracting = "extracting"
+)
+
+// progressTimeout bounds each progress report so a slow grpc-service does
+// not hold up extraction.
+const progressTimeout = 5 * time.Second
+
+// ProgressPayload is the body of a progress callback. StartedAt is when the
+// worker picked the document up; Events are timeline events not yet sent.
+type ProgressPayload struct {
+	TenantID  string          `json:"tenant_id,omitempty"`
+	WorkerID  string          `json:"worker_id"`
+	StartedAt time.Time       `json:"started_at"`
+	Stage     string          `json:"stage"`
+	Message   string          `json:"message,omitempty"`
+	Events    []TimelineEvent `json:"events,omitempty"`
+}
+
+// reporter tells the grpc-service what the consumer is doing with one
+// document. It always reports the pickup, and each later stage when updates
+// is set. Timeline events collected in between go with the next report that
+// gets through; any still unsent travel with the results.
+type reporter struct {
+	timeline
+	cfg     GRPCServiceConfig
+	docID   string
+	base    ProgressPayload
+	updates bool
+}
+
+// stage reports that the document entered stage. Failures are logged and
+// counted but never stop processing.
+func (r *reporter) stage(ctx context.Context, stage, message string) {
+	if stage != stagePickedUp && !r.updates {
+		return
+	}
+	p := r.base
+	p.Stage, p.Message, p.Events = stage, message, r.events
+
+	ctx, cancel := context.WithTimeout(ctx, progressTimeout)
+	defer cancel()
+	progressReports.Inc()
+	if err := postCallback(ctx, r.cfg, "/documents/"+r.docID+"/progress", p); err != nil {
+		progressFailures.Inc()
+		slog.WarnContext(ctx, "progress report failed", "stage", stage, "error", err)
+		return
+	}
+	r.events = nil
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Kinds of error kept for GET /status.
+const (
+	errorInvalidMessage = "invalid_message"
+	errorNLP            = "nlp"
+	errorCallback       = "callback"
+	errorConsumerGroup  = "consumer_group"
+	errorDeadLetter     = "dead_letter"
+)
+
+// maxRecentErrors bounds the errors kept for GET /status; older ones are
+// dropped.
+const maxRecentErrors = 50
+
+// tracker keeps what the consumer is doing for the admin server, and
+// pauses and resumes consumption. It is safe for concurrent use.
+type tracker struct {
+	mu         sync.Mutex
+	group      sarama.ConsumerGroup
+	memberID   string
+	generation int32
+	claims     map[int32]*claimState
+	inFlight   map[topicPartition]InFlight
+	errors     []RecentError
+	paused     bool
+	pausedAt   time.Time
+	resumed    chan struct{} // closed on resume
+}
+
+// claimState is one assigned partition. next is the offset of the next
+// message to process, or a sarama.Offset* sentinel until one is processed
+// when the group has no committed offset.
+type claimState struct {
+	claim sarama.ConsumerGroupClaim
+	next  int64
+}
+
+// topicPartition keys the messages in flight. Sarama consumes each claimed
+// partition in its own goroutine, so there is at most one per partition.
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+// InFlight is a message being processed.
+type InFlight struct {
+	DocumentID string    `json:"document_id"`
+	TenantID   string    `json:"tenant_id"`
+	Filename   string    `json:"filename"`
+	Topic      string    `json:"topic"`
+	Partition  int32     `json:"partition"`
+	Offset     int64     `json:"offset"`
+	RequestID  string    `json:"request_id"`
+	StartedAt  time.Time `json:"started_at"`
+}
+
+// RecentError is a failure worth an operator's attention.
+type RecentError struct {
+	At         time.Time `json:"at"`
+	Kind       string    `json:"kind"`
+	DocumentID string    `json:"document_id,omitempty"`
+	Error      string    `json:"error"`
+}
+
+// PartitionStatus is one assigned partition's position. Offset is -1 until
+// known.
+type PartitionStatus struct {
+	Partition     int32 `json:"partition"`
+	Offset        int64 `json:"offset"`
+	HighWaterMark int64 `json:"high_water_mark"`
+	Lag           int64 `json:"lag"`
+}
+
+// Status is served at GET /status.
+type Status struct {
+	WorkerID     string            `json:"worker_id"`
+	Topic        string            `json:"topic"`
+	Group        string            `json:"group"`
+	MemberID     string            `json:"member_id"`
+	Generation   int32             `json:"generation"`
+	Paused       bool              `json:"paused"`
+	PausedAt     *time.Time        `json:"paused_at,omitempty"`
+	Partitions   []PartitionStatus `json:"partitions"`
+	InFlight     []InFlight        `json:"in_flight"`
+	RecentErrors []RecentError     `json:"recent_errors"`
+}
+
+func newTracker() *tracker {
+	return &tracker{claims: make(map[int32]*claimState), inFlight: make(map[topicPartition]InFlight)}
+}
+
+// setGroup gives the tracker the consumer group to pause and resume.
+func (t *tracker) setGroup(g sarama.ConsumerGroup) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.group = g
+}
+
+// sessionStarted records a new group session's member ID and generation.
+func (t *tracker) sessionStarted(sess sarama.ConsumerGroupSession) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.memberID, t.generation = sess.MemberID(), sess.GenerationID()
+	t.claims = make(map[int32]*claimState)
+}
+
+// sessionEnded forgets the session's partitions, which may be reassigned.
+func (t *tracker) sessionEnded() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.claims = make(map[int32]*claimState)
+}
+
+// claimed adds an assigned partition. Pausing only covers the partitions
+// assigned at the time, so a partition claimed while paused is paused too.
+func (t *tracker) claimed(claim sarama.ConsumerGroupClaim) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.claims[claim.Partition()] = &claimState{claim: claim, next: claim.InitialOffset()}
+	if t.paused && t.group != nil {
+		t.group.Pause(map[string][]int32{claim.Topic(): {claim.Partition()}})
+	}
+}
+
+// processed records that offset on partition is done.
+func (t *tracker) processed(partition int32, offset int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if c, ok := t.claims[partition]; ok {
+		c.next = offset + 1
+	}
+}
+
+// begin records the message now being processed on m's partition; end
+// clears it.
+func (t *tracker) begin(m InFlight) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.inFlight[topicPartition{m.Topic, m.Partition}] = m
+}
+
+func (t *tracker) end(topic string, partition int32) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.inFlight, topicPartition{topic, partition})
+}
+
+// fail keeps err as a recent error of kind.
+func (t *tracker) fail(kind, documentID string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errors = append(t.errors, RecentError{At: time.Now().UTC(), Kind: kind, DocumentID: documentID, Error: err.Error()})
+	if n := len(t.errors); n > maxRecentErrors {
+		t.errors = append(t.errors[:0], t.errors[n-maxRecentErrors:]...)
+	}
+}
+
+// pause stops fetching from every assigned partition and holds back the
+// next messages; those in flight finish. It returns false if already
+// paused.
+func (t *tracker) pause() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.paused {
+		return false
+	}
+	t.paused, t.pausedAt = true, time.Now().UTC()
+	t.resumed = make(chan struct{})
+	if t.group != nil {
+		t.group.PauseAll()
+	}
+	return true
+}
+
+// resume undoes pause. It returns false if not paused.
+func (t *tracker) resume() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.paused {
+		return false
+	}
+	t.paused, t.pausedAt = false, time.Time{}
+	close(t.resumed)
+	if t.group != nil {
+		t.group.ResumeAll()
+	}
+	return true
+}
+
+// resumedCh returns a channel closed on resume, or nil when not paused.
+func (t *tracker) resumedCh() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.paused {
+		return nil
+	}
+	return t.resumed
+}
+
+// status reports the tracker's state; recent errors are newest first.
+func (t *tracker) status() Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := Status{
+		MemberID:     t.memberID,
+		Generation:   t.generation,
+		Paused:       t.paused,
+		Partitions:   make([]PartitionStatus, 0, len(t.claims)),
+		InFlight:     make([]InFlight, 0, len(t.inFlight)),
+		RecentErrors: make([]RecentError, 0, len(t.errors)),
+	}
+	if t.paused {
+		at := t.pausedAt
+		s.PausedAt = &at
+	}
+	for partition, c := range t.claims {
+		ps := PartitionStatus{Partition: partition, Offset: -1, HighWaterMark: c.claim.HighWaterMarkOffset()}
+		if c.next >= 0 {
+			ps.Offset = c.next
+			if lag := ps.HighWaterMark - c.next; lag > 0 {
+				ps.Lag = lag
+			}
+		}
+		s.Partitions = append(s.Partitions, ps)
+	}
+	sort.Slice(s.Partitions, func(i, j int) bool { return s.Partitions[i].Partition < s.Partitions[j].Partition })
+	for _, m := range t.inFlight {
+		s.InFlight = append(s.InFlight, m)
+	}
+	sort.Slice(s.InFlight, func(i, j int) bool {
+		a, b := s.InFlight[i], s.InFlight[j]
+		if a.Topic != b.Topic {
+			return a.Topic < b.Topic
+		}
+		return a.Partition < b.Partition
+	})
+	for i := len(t.errors) - 1; i >= 0; i-- {
+		s.RecentErrors = append(s.RecentErrors, t.errors[i])
+	}
+	return s
+}
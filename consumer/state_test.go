@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+// fakeGroup records the pause and resume calls the tracker makes.
+type fakeGroup struct {
+	sarama.ConsumerGroup
+	calls []string
+}
+
+func (g *fakeGroup) PauseAll()  { g.calls = append(g.calls, "pause all") }
+func (g *fakeGroup) ResumeAll() { g.calls = append(g.calls, "resume all") }
+func (g *fakeGroup) Pause(partitions map[string][]int32) {
+	for topic, ps := range partitions {
+		g.calls = append(g.calls, fmt.Sprintf("pause %s/%v", topic, ps))
+	}
+}
+
+// fakeClaim is an assigned partition.
+type fakeClaim struct {
+	sarama.ConsumerGroupClaim
+	partition int32
+	initial   int64
+	hwm       int64
+}
+
+func (c *fakeClaim) Topic() string              { return "document-uploads" }
+func (c *fakeClaim) Partition() int32           { return c.partition }
+func (c *fakeClaim) InitialOffset() int64       { return c.initial }
+func (c *fakeClaim) HighWaterMarkOffset() int64 { return c.hwm }
+
+func TestTrackerPauseResume(t *testing.T) {
+	g := &fakeGroup{}
+	tr := newTracker()
+	tr.setGroup(g)
+
+	if tr.resumedCh() != nil {
+		t.Fatal("resumedCh is set before pausing")
+	}
+	if tr.resume() {
+		t.Error("resume while running reported a change")
+	}
+	if !tr.pause() {
+		t.Fatal("pause reported no change")
+	}
+	if tr.pause() {
+		t.Error("second pause reported a change")
+	}
+	ch := tr.resumedCh()
+	if ch == nil {
+		t.Fatal("resumedCh is nil while paused")
+	}
+	if st := tr.status(); !st.Paused || st.PausedAt == nil {
+		t.Errorf("status while paused = %+v", st)
+	}
+
+	// A partition assigned during the pause starts paused.
+	tr.claimed(&fakeClaim{partition: 3, initial: sarama.OffsetNewest})
+
+	if !tr.resume() {
+		t.Fatal("resume reported no change")
+	}
+	select {
+	case <-ch:
+	default:
+		t.Error("resume did not release waiting handlers")
+	}
+	if st := tr.status(); st.Paused || st.PausedAt != nil {
+		t.Errorf("status after resume = %+v", st)
+	}
+
+	want := []string{"pause all", "pause document-uploads/[3]", "resume all"}
+	if !reflect.DeepEqual(g.calls, want) {
+		t.Errorf("group calls = %q, want %q", g.calls, want)
+	}
+}
+
+func TestTrackerStatus(t *testing.T) {
+	tr := newTracker()
+	tr.claimed(&fakeClaim{partition: 1, initial: 100, hwm: 130})
+	tr.claimed(&fakeClaim{partition: 0, initial: sarama.OffsetOldest, hwm: 8})
+	tr.claimed(&fakeClaim{partition: 2, initial: 40, hwm: 40})
+	tr.processed(1, 119)
+	tr.processed(7, 5) // not assigned; ignored
+
+	tr.begin(InFlight{DocumentID: "b", Topic: "document-uploads", Partition: 1, Offset: 120})
+	tr.begin(InFlight{DocumentID: "a", Topic: "document-uploads", Partition: 0, Offset: 0})
+	tr.begin(InFlight{DocumentID: "c", Topic: "document-uploads", Partition: 0, Offset: 1})
+
+	st := tr.status()
+	wantParts := []PartitionStatus{
+		{Partition: 0, Offset: -1, HighWaterMark: 8},
+		{Partition: 1, Offset: 120, HighWaterMark: 130, Lag: 10},
+		{Partition: 2, Offset: 40, HighWaterMark: 40},
+	}
+	if !reflect.DeepEqual(st.Partitions, wantParts) {
+		t.Errorf("partitions = %+v, want %+v", st.Partitions, wantParts)
+	}
+	// One message per partition: the later begin replaces the earlier.
+	var docs []string
+	for _, m := range st.InFlight {
+		docs = append(docs, m.DocumentID)
+	}
+	if !reflect.DeepEqual(docs, []string{"c", "b"}) {
+		t.Errorf("in flight = %q, want [c b]", docs)
+	}
+
+	tr.end("document-uploads", 0)
+	tr.end("document-uploads", 1)
+	if n := len(tr.status().InFlight); n != 0 {
+		t.Errorf("%d in flight after end, want 0", n)
+	}
+
+	// A new session forgets the old one's partitions.
+	tr.sessionEnded()
+	if n := len(tr.status().Partitions); n != 0 {
+		t.Errorf("%d partitions after the session ended, want 0", n)
+	}
+}
+
+func TestTrackerKeepsRecentErrors(t *testing.T) {
+	tr := newTracker()
+	for i := range maxRecentErrors + 5 {
+		tr.fail(errorNLP, fmt.Sprintf("doc-%d", i), errors.New("nlp service: 503"))
+	}
+	tr.fail(errorCallback, "", errors.New("callback: 500"))
+
+	errs := tr.status().RecentErrors
+	if len(errs) != maxRecentErrors {
+		t.Fatalf("%d recent errors, want %d", len(errs), maxRecentErrors)
+	}
+	if newest := errs[0]; newest.Kind != errorCallback || newest.Error != "callback: 500" {
+		t.Errorf("newest = %+v, want the callback error", newest)
+	}
+	if oldest := errs[len(errs)-1]; oldest.DocumentID != "doc-6" {
+		t.Errorf("oldest kept = %s, want doc-6", oldest.DocumentID)
+	}
+}
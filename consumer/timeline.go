@@ -0,0 +1,51 @@
+package main
+
+import (
+	"strconv"
+	"time"
+)
+
+// Timeline event types the consumer reports; see the grpc-service's
+// GET /documents/{id}/timeline.
+const (
+	eventPickedUp   = "picked_up"
+	eventNLPAttempt = "nlp_attempt"
+)
+
+// maxEventError bounds the error text attached to an event.
+const maxEventError = 300
+
+// TimelineEvent is one event sent with a document's results. The
+// grpc-service attributes it to the payload's worker.
+type TimelineEvent struct {
+	Type   string            `json:"type"`
+	At     time.Time         `json:"at"`
+	Detail map[string]string `json:"detail,omitempty"`
+}
+
+// timeline collects the events for one document.
+type timeline struct {
+	events []TimelineEvent
+}
+
+func (t *timeline) add(typ string, detail map[string]string) {
+	t.events = append(t.events, TimelineEvent{Type: typ, At: time.Now().UTC(), Detail: detail})
+}
+
+// nlpAttempt records one call to the NLP service; err is nil on success.
+func (t *timeline) nlpAttempt(attempt int, took time.Duration, err error) {
+	detail := map[string]string{
+		"attempt":     strconv.Itoa(attempt),
+		"duration_ms": strconv.FormatInt(took.Milliseconds(), 10),
+		"outcome":     "ok",
+	}
+	if err != nil {
+		detail["outcome"] = "error"
+		msg := err.Error()
+		if len(msg) > maxEventError {
+			msg = msg[:maxEventError] + "..."
+		}
+		detail["error"] = msg
+	}
+	t.add(eventNLPAttempt, detail)
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// httpClient is used for every call to the NLP service and the grpc-service.
+// main replaces it with one configured for TLS when upstream TLS is set, and
+// then wraps it to propagate trace context.
+var httpClient = http.DefaultClient
+
+// tlsCheckInterval limits how often certificate files are stat'ed for changes.
+const tlsCheckInterval = 5 * time.Second
+
+// upstreamTLS holds the CA bundle and optional client certificate used to
+// reach HTTPS upstreams, reloading them when the files change. Reloaded
+// material applies to new connections.
+type upstreamTLS struct {
+	caFile, certFile, keyFile string
+	serverName                string
+
+	mu       sync.Mutex
+	roots    *x509.CertPool
+	cert     *tls.Certificate
+	modTimes map[string]time.Time
+	checked  time.Time
+}
+
+// newUpstreamTLS returns nil when neither a CA file nor a client
+// certificate is configured.
+func newUpstreamTLS(cfg UpstreamTLSConfig) (*upstreamTLS, error) {
+	u := &upstreamTLS{
+		caFile:     cfg.CAFile,
+		certFile:   cfg.CertFile,
+		keyFile:    cfg.KeyFile,
+		serverName: cfg.ServerName,
+	}
+	if u.caFile == "" && u.certFile == "" {
+		return nil, nil
+	}
+	if (u.certFile == "") != (u.keyFile == "") {
+		return nil, fmt.Errorf("client certificate and key must be set together")
+	}
+	if err := u.load(); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (u *upstreamTLS) files() []string {
+	var files []string
+	for _, f := range []string{u.caFile, u.certFile, u.keyFile} {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// load reads every file. Callers must hold u.mu, except during construction.
+func (u *upstreamTLS) load() error {
+	modTimes := make(map[string]time.Time)
+	for _, f := range u.files() {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return err
+		}
+		modTimes[f] = fi.ModTime()
+	}
+
+	var roots *x509.CertPool
+	if u.caFile != "" {
+		pem, err := os.ReadFile(u.caFile)
+		if err != nil {
+			return fmt.Errorf("read CA file: %w", err)
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates in %s", u.caFile)
+		}
+	}
+	var cert *tls.Certificate
+	if u.certFile != "" {
+		c, err := tls.LoadX509KeyPair(u.certFile, u.keyFile)
+		if err != nil {
+			return fmt.Errorf("load client key pair: %w", err)
+		}
+		cert = &c
+	}
+
+	u.roots, u.cert, u.modTimes = roots, cert, modTimes
+	return nil
+}
+
+// config returns a client configuration for serverName, first reloading
+// the files if they changed.
+func (u *upstreamTLS) config(serverName string) *tls.Config {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if time.Since(u.checked) >= tlsCheckInterval {
+		u.checked = time.Now()
+		for _, f := range u.files() {
+			if fi, err := os.Stat(f); err == nil && !fi.ModTime().Equal(u.modTimes[f]) {
+				if err := u.load(); err != nil {
+					slog.Error("upstream TLS reload failed, keeping previous certificates", "error", err)
+				} else {
+					slog.Info("upstream TLS certificates reloaded")
+				}
+				break
+			}
+		}
+	}
+
+	if u.serverName != "" {
+		serverName = u.serverName
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: serverName, RootCAs: u.roots}
+	if u.cert != nil {
+		cfg.Certificates = []tls.Certificate{*u.cert}
+	}
+	return cfg
+}
+
+// newHTTPClient returns a client whose TLS connections use u.
+func newHTTPClient(u *upstreamTLS) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := dialer.DialContext(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		conn := tls.Client(raw, u.config(host))
+		if err := conn.HandshakeContext(ctx); err != nil {
+			raw.Close()
+			return nil, err
+		}
+		return conn, nil
+	}
+	return &http.Client{Transport: transport}
+}
@@ -1,4 +1,9 @@
 const API_BASE = import.meta.env.VITE_API_URL || 'http://localhost:8080';
+const API_KEY = import.meta.env.VITE_API_KEY || '';
+
+function authHeaders() {
+  return API_KEY ? { 'X-API-Key': API_KEY } : {};
+}
 
 export async function uploadDocument(file, dataPoints) {
   const formData = new FormData();
@@ -7,6 +12,7 @@ export async function uploadDocument(file, dataPoints) {
 
   const response = await fetch(`${API_BASE}/documents`, {
     method: 'POST',
+    headers: authHeaders(),
     body: formData,
   });
 
@@ -19,7 +25,7 @@ export async function uploadDocument(file, dataPoints) {
 }
 
 export async function listDocuments() {
-  const response = await fetch(`${API_BASE}/documents`);
+  const response = await fetch(`${API_BASE}/documents`, { headers: authHeaders() });
 
   if (!response.ok) {
     throw new Error(`Failed to list documents: ${response.status}`);
@@ -29,7 +35,9 @@ export async function listDocuments() {
 }
 
 export async function getDataPoints(documentId) {
-  const response = await fetch(`${API_BASE}/documents/${documentId}/datapoints`);
+  const response = await fetch(`${API_BASE}/documents/${documentId}/datapoints`, {
+    headers: authHeaders(),
+  });
 
   if (!response.ok) {
     throw new Error(`Failed to get data points: ${response.status}`);
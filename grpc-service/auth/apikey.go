@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// APIKey is one entry of an API keys file. Only the SHA-256 of the key is
+// stored, e.g. the output of `printf %s "$KEY" | sha256sum`.
+type APIKey struct {
+	KeySHA256 string `json:"key_sha256"`
+	Subject   string `json:"subject"`
+	// Tenant scopes the key's documents; empty means DefaultTenant.
+	Tenant string `json:"tenant,omitempty"`
+	// Roles are granted to every caller using the key.
+	Roles []string `json:"roles,omitempty"`
+}
+
+// APIKeys authenticates static API keys.
+type APIKeys struct {
+	keys []apiKey
+}
+
+type apiKey struct {
+	hash    []byte
+	subject string
+	tenant  string
+	roles   []string
+}
+
+// LoadAPIKeys reads a JSON file of the form
+// {"keys": [{"key_sha256": "...", "subject": "...", "tenant": "...", "roles": ["..."]}]}.
+func LoadAPIKeys(path string) (*APIKeys, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("auth: read api keys: %w", err)
+	}
+	var file struct {
+		Keys []APIKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("auth: parse api keys: %w", err)
+	}
+	return NewAPIKeys(file.Keys)
+}
+
+// NewAPIKeys builds an APIKeys authenticator from entries.
+func NewAPIKeys(entries []APIKey) (*APIKeys, error) {
+	a := &APIKeys{}
+	for i, e := range entries {
+		hash, err := hex.DecodeString(e.KeySHA256)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("auth: api key %d: key_sha256 must be 64 hex characters", i)
+		}
+		if e.Subject == "" {
+			return nil, fmt.Errorf("auth: api key %d: subject is required", i)
+		}
+		a.keys = append(a.keys, apiKey{hash: hash, subject: e.Subject, tenant: e.Tenant, roles: e.Roles})
+	}
+	return a, nil
+}
+
+// Authenticate implements Authenticator.
+func (a *APIKeys) Authenticate(_ context.Context, cred Credential) (*Identity, error) {
+	if cred.Scheme != SchemeAPIKey {
+		return nil, ErrNoCredentials
+	}
+	sum := sha256.Sum256([]byte(cred.Token))
+	var match *apiKey
+	for i := range a.keys {
+		// Compare against every key so timing does not reveal which matched.
+		if subtle.ConstantTimeCompare(sum[:], a.keys[i].hash) == 1 {
+			match = &a.keys[i]
+		}
+	}
+	if match == nil {
+		return nil, ErrInvalidCredentials
+	}
+	return &Identity{Subject: match.subject, Method: MethodAPIKey, Tenant: match.tenant, Roles: match.roles}, nil
+}
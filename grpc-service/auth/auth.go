@@ -0,0 +1,123 @@
+// Package auth authenticates callers of the REST and gRPC APIs.
+//
+// Two credential types are supported and may be enabled together:
+//
+//   - static API keys, sent as "X-API-Key: <key>" (gRPC metadata "x-api-key")
+//     or "Authorization: ApiKey <key>";
+//   - JWT bearer tokens, sent as "Authorization: Bearer <token>", verified
+//     against keys from a local JWKS file.
+//
+// The authenticated caller is stored in the request context; handlers read it
+// with FromContext. Every caller belongs to a tenant (TenantFromContext);
+// callers without one, including all callers when authentication is disabled,
+// share DefaultTenant.
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Authentication methods recorded on Identity.
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+)
+
+// DefaultTenant owns documents uploaded by callers that carry no tenant.
+const DefaultTenant = "default"
+
+// Credential schemes.
+const (
+	SchemeAPIKey = "apikey"
+	SchemeBearer = "bearer"
+)
+
+var (
+	// ErrNoCredentials means the request carried no credential at all.
+	ErrNoCredentials = errors.New("auth: no credentials")
+	// ErrInvalidCredentials means a credential was presented but rejected.
+	ErrInvalidCredentials = errors.New("auth: invalid credentials")
+)
+
+// Identity is an authenticated caller.
+type Identity struct {
+	// Subject names the caller: the API key's subject or the JWT "sub" claim.
+	Subject string
+	// Method is MethodAPIKey, MethodJWT or MethodHMAC.
+	Method string
+	// Tenant is the tenant the caller acts for; empty means DefaultTenant.
+	Tenant string
+	// Roles are the roles asserted by the credential itself; a Policy may
+	// add more.
+	Roles []string
+	// Claims holds the verified JWT claims; nil for API keys.
+	Claims map[string]interface{}
+}
+
+// Credential is a secret presented by a caller.
+type Credential struct {
+	Scheme string // SchemeAPIKey or SchemeBearer
+	Token  string
+}
+
+// Authenticator verifies credentials of the schemes it understands.
+type Authenticator interface {
+	// Authenticate returns the caller's identity. It returns
+	// ErrNoCredentials for schemes it does not handle so a Chain can try
+	// the next authenticator.
+	Authenticate(ctx context.Context, cred Credential) (*Identity, error)
+}
+
+// Chain tries each Authenticator in order until one accepts or rejects the
+// credential.
+type Chain []Authenticator
+
+// Authenticate implements Authenticator.
+func (c Chain) Authenticate(ctx context.Context, cred Credential) (*Identity, error) {
+	for _, a := range c {
+		id, err := a.Authenticate(ctx, cred)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return id, err
+	}
+	return nil, ErrNoCredentials
+}
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying id.
+func NewContext(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the caller stored by the middleware or interceptors,
+// if any.
+func FromContext(ctx context.Context) (*Identity, bool) {
+	id, ok := ctx.Value(contextKey{}).(*Identity)
+	return id, ok && id != nil
+}
+
+// TenantFromContext returns the tenant of the caller stored in ctx, or
+// DefaultTenant.
+func TenantFromContext(ctx context.Context) string {
+	if id, ok := FromContext(ctx); ok && id.Tenant != "" {
+		return id.Tenant
+	}
+	return DefaultTenant
+}
+
+// parseCredential reads a credential from an Authorization header value or an
+// API key header value. Either may be empty.
+func parseCredential(authorization, apiKey string) (Credential, bool) {
+	if apiKey != "" {
+		return Credential{Scheme: SchemeAPIKey, Token: apiKey}, true
+	}
+	scheme, token, ok := strings.Cut(strings.TrimSpace(authorization), " ")
+	if !ok || token == "" {
+		return Credential{}, false
+	}
+	return Credential{Scheme: strings.ToLower(scheme), Token: strings.TrimSpace(token)}, true
+}
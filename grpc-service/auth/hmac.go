@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MethodHMAC marks an Identity established by a signed service-to-service
+// request.
+const MethodHMAC = "hmac"
+
+// Headers carrying a request signature.
+const (
+	HeaderTimestamp = "X-Callback-Timestamp"
+	HeaderSignature = "X-Callback-Signature"
+)
+
+// maxSignedBody caps how much of a signed request body is read.
+const maxSignedBody = 16 << 20
+
+// Sign returns the signature header value for a request: the hex HMAC-SHA256,
+// keyed by secret, of "<timestamp>.<METHOD>.<path>." followed by the body.
+func Sign(secret []byte, timestamp int64, method, path string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%d.%s.%s.", timestamp, method, path)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// HMACVerifier authenticates requests signed with a shared secret. A
+// signature is accepted once, and only while its timestamp is within MaxSkew
+// of the server clock.
+type HMACVerifier struct {
+	secret  []byte
+	subject string
+	maxSkew time.Duration
+	now     func() time.Time
+
+	mu   sync.Mutex
+	seen map[string]time.Time // signature -> expiry
+}
+
+// NewHMACVerifier returns a verifier for secret. Verified callers get an
+// Identity with the given subject and RoleConsumer.
+func NewHMACVerifier(secret []byte, subject string, maxSkew time.Duration) *HMACVerifier {
+	return &HMACVerifier{
+		secret:  secret,
+		subject: subject,
+		maxSkew: maxSkew,
+		now:     time.Now,
+		seen:    make(map[string]time.Time),
+	}
+}
+
+// Verify checks r's signature. It consumes and restores r.Body.
+func (v *HMACVerifier) Verify(r *http.Request) (*Identity, error) {
+	tsHeader, sig := r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature)
+	if tsHeader == "" || sig == "" {
+		return nil, ErrNoCredentials
+	}
+	ts, err := strconv.ParseInt(tsHeader, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad timestamp", ErrInvalidCredentials)
+	}
+	now := v.now()
+	if d := now.Sub(time.Unix(ts, 0)); d > v.maxSkew || d < -v.maxSkew {
+		return nil, fmt.Errorf("%w: timestamp outside allowed skew", ErrInvalidCredentials)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBody))
+	if err != nil {
+		return nil, fmt.Errorf("%w: read body: %v", ErrInvalidCredentials, err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	want := Sign(v.secret, ts, r.Method, r.URL.Path, body)
+	if !hmac.Equal([]byte(strings.ToLower(sig)), []byte(want)) {
+		return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidCredentials)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for s, exp := range v.seen {
+		if now.After(exp) {
+			delete(v.seen, s)
+		}
+	}
+	if _, dup := v.seen[want]; dup {
+		return nil, fmt.Errorf("%w: replayed signature", ErrInvalidCredentials)
+	}
+	v.seen[want] = time.Unix(ts, 0).Add(v.maxSkew)
+
+	return &Identity{Subject: v.subject, Method: MethodHMAC, Roles: []string{RoleConsumer}}, nil
+}
+
+// HMACMiddleware admits only requests signed for v, storing the resulting
+// Identity in the request context. A nil verifier rejects every request.
+func HMACMiddleware(v *HMACVerifier, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if v == nil {
+			http.Error(w, "service callbacks are disabled", http.StatusForbidden)
+			return
+		}
+		id, err := v.Verify(r)
+		if err != nil {
+			slog.WarnContext(r.Context(), "auth: rejected callback", "method", r.Method, "path", r.URL.Path, "error", err)
+			if errors.Is(err, ErrNoCredentials) {
+				http.Error(w, "signed request required", http.StatusUnauthorized)
+			} else {
+				http.Error(w, "invalid signature", http.StatusUnauthorized)
+			}
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+	})
+}
+
+// IsServiceCall reports whether ctx belongs to a verified signed request.
+func IsServiceCall(ctx context.Context) bool {
+	id, ok := FromContext(ctx)
+	return ok && id.Method == MethodHMAC
+}
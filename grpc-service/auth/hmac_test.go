@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var hmacNow = time.Unix(1_800_000_000, 0)
+
+const hmacPath = "/internal/documents/doc-1/results"
+
+// signedRequest returns a POST of body to hmacPath signed with secret at ts.
+func signedRequest(secret string, ts time.Time, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, hmacPath, strings.NewReader(body))
+	r.Header.Set(HeaderTimestamp, strconv.FormatInt(ts.Unix(), 10))
+	r.Header.Set(HeaderSignature, Sign([]byte(secret), ts.Unix(), http.MethodPost, hmacPath, []byte(body)))
+	return r
+}
+
+func TestHMACVerify(t *testing.T) {
+	const body = `{"status":"completed"}`
+	tests := []struct {
+		name    string
+		req     func() *http.Request
+		wantErr error // nil for success
+		wantMsg string
+	}{
+		{"valid", func() *http.Request { return signedRequest("secret", hmacNow, body) }, nil, ""},
+		{"upper-case signature", func() *http.Request {
+			r := signedRequest("secret", hmacNow, body)
+			r.Header.Set(HeaderSignature, strings.ToUpper(r.Header.Get(HeaderSignature)))
+			return r
+		}, nil, ""},
+		{"skew at limit in the past", func() *http.Request {
+			return signedRequest("secret", hmacNow.Add(-5*time.Minute), body)
+		}, nil, ""},
+		{"skew at limit in the future", func() *http.Request {
+			return signedRequest("secret", hmacNow.Add(5*time.Minute), body)
+		}, nil, ""},
+		{"too old", func() *http.Request {
+			return signedRequest("secret", hmacNow.Add(-5*time.Minute-time.Second), body)
+		}, ErrInvalidCredentials, "outside allowed skew"},
+		{"too far ahead", func() *http.Request {
+			return signedRequest("secret", hmacNow.Add(5*time.Minute+time.Second), body)
+		}, ErrInvalidCredentials, "outside allowed skew"},
+		{"tampered body", func() *http.Request {
+			r := signedRequest("secret", hmacNow, body)
+			r.Body = io.NopCloser(strings.NewReader(`{"status":"failed"}`))
+			return r
+		}, ErrInvalidCredentials, "signature mismatch"},
+		{"tampered path", func() *http.Request {
+			r := signedRequest("secret", hmacNow, body)
+			r.URL.Path = "/internal/documents/doc-2/results"
+			return r
+		}, ErrInvalidCredentials, "signature mismatch"},
+		{"tampered timestamp", func() *http.Request {
+			r := signedRequest("secret", hmacNow, body)
+			r.Header.Set(HeaderTimestamp, strconv.FormatInt(hmacNow.Unix()+1, 10))
+			return r
+		}, ErrInvalidCredentials, "signature mismatch"},
+		{"wrong secret", func() *http.Request { return signedRequest("other", hmacNow, body) }, ErrInvalidCredentials, "signature mismatch"},
+		{"bad timestamp", func() *http.Request {
+			r := signedRequest("secret", hmacNow, body)
+			r.Header.Set(HeaderTimestamp, "yesterday")
+			return r
+		}, ErrInvalidCredentials, "bad timestamp"},
+		{"unsigned", func() *http.Request {
+			return httptest.NewRequest(http.MethodPost, hmacPath, strings.NewReader(body))
+		}, ErrNoCredentials, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewHMACVerifier([]byte("secret"), "consumer", 5*time.Minute)
+			v.now = func() time.Time { return hmacNow }
+			r := tt.req()
+			id, err := v.Verify(r)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) || !strings.Contains(err.Error(), tt.wantMsg) {
+					t.Fatalf("err = %v, want %v containing %q", err, tt.wantErr, tt.wantMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if id.Subject != "consumer" || id.Method != MethodHMAC {
+				t.Errorf("identity = %+v", id)
+			}
+			if got, _ := io.ReadAll(r.Body); string(got) != body {
+				t.Errorf("body after Verify = %q, want it restored", got)
+			}
+		})
+	}
+}
+
+func TestHMACVerifyReplay(t *testing.T) {
+	const body = `{"status":"completed"}`
+	now := hmacNow
+	v := NewHMACVerifier([]byte("secret"), "consumer", 5*time.Minute)
+	v.now = func() time.Time { return now }
+
+	steps := []struct {
+		name    string
+		at      time.Time
+		signed  time.Time
+		body    string
+		wantErr string // empty for success
+	}{
+		{"first use", hmacNow, hmacNow, body, ""},
+		{"replay", hmacNow.Add(time.Second), hmacNow, body, "replayed signature"},
+		{"same time, other body", hmacNow.Add(time.Second), hmacNow, `{"status":"failed"}`, ""},
+		{"replay after the skew window", hmacNow.Add(5*time.Minute + time.Second), hmacNow, body, "outside allowed skew"},
+	}
+	for _, s := range steps {
+		now = s.at
+		_, err := v.Verify(signedRequest("secret", s.signed, s.body))
+		switch {
+		case s.wantErr == "" && err != nil:
+			t.Errorf("%s: err = %v", s.name, err)
+		case s.wantErr != "" && (!errors.Is(err, ErrInvalidCredentials) || !strings.Contains(err.Error(), s.wantErr)):
+			t.Errorf("%s: err = %v, want ErrInvalidCredentials containing %q", s.name, err, s.wantErr)
+		}
+	}
+}
+
+func TestHMACMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsServiceCall(r.Context()) {
+			t.Error("identity not stored in the request context")
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	v := NewHMACVerifier([]byte("secret"), "consumer", 5*time.Minute)
+	v.now = func() time.Time { return hmacNow }
+
+	tests := []struct {
+		name     string
+		verifier *HMACVerifier
+		req      *http.Request
+		want     int
+	}{
+		{"signed", v, signedRequest("secret", hmacNow, "{}"), http.StatusNoContent},
+		{"bad signature", v, signedRequest("other", hmacNow, "{}"), http.StatusUnauthorized},
+		{"unsigned", v, httptest.NewRequest(http.MethodPost, hmacPath, nil), http.StatusUnauthorized},
+		{"disabled", nil, signedRequest("secret", hmacNow, "{}"), http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			HMACMiddleware(tt.verifier, next).ServeHTTP(w, tt.req)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var jwtNow = time.Unix(1_800_000_000, 0)
+
+// testKeys are an RSA key restricted to RS256 under kid "rsa" and a P-256
+// key with no alg under kid "ec".
+type testKeys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+func newTestJWT(t *testing.T, cfg JWTConfig) (*JWT, testKeys) {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	set := map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa", "alg": "RS256", "use": "sig",
+			"n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec", "crv": "P-256",
+			"x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+	}}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.JWKSFile = filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(cfg.JWKSFile, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	j, err := NewJWT(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.now = func() time.Time { return jwtNow }
+	return j, testKeys{rsaKey, ecKey}
+}
+
+// signJWT returns a compact JWT over claims signed with alg by key.
+func signJWT(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	t.Helper()
+	enc := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	signed := enc(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"}) + "." + enc(claims)
+	digest := crypto.SHA256.New()
+	digest.Write([]byte(signed))
+	sum := digest.Sum(nil)
+
+	var sig []byte
+	var err error
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if strings.HasPrefix(alg, "PS") {
+			sig, err = rsa.SignPSS(rand.Reader, k, crypto.SHA256, sum, nil)
+		} else {
+			sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, sum)
+		}
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, sum)
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestJWTAuthenticate(t *testing.T) {
+	j, keys := newTestJWT(t, JWTConfig{
+		Issuer:   "https://issuer.example",
+		Audience: "pdf-extractor",
+		Leeway:   30 * time.Second,
+	})
+	claims := func(edit func(map[string]interface{})) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub":    "alice",
+			"iss":    "https://issuer.example",
+			"aud":    "pdf-extractor",
+			"exp":    jwtNow.Add(time.Hour).Unix(),
+			"tenant": "acme",
+			"roles":  []string{"uploader"},
+		}
+		if edit != nil {
+			edit(c)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr string // empty for success
+	}{
+		{"rs256", signJWT(t, "RS256", "rsa", keys.rsa, claims(nil)), ""},
+		{"es256", signJWT(t, "ES256", "ec", keys.ec, claims(nil)), ""},
+		{"audience list", signJWT(t, "RS256", "rsa", keys.rsa, claims(func(c map[string]interface{}) {
+			c["aud"] = []string{"other", "pdf-extractor"}
+		})), ""},
+		{"alg not allowed for key", signJWT(t, "PS256", "rsa", keys.rsa, claims(nil)), "does not allow alg PS256"},
+		{"alg none", func() string {
+			tok := signJWT(t, "none", "ec", keys.ec, claims(nil))
+			return tok[:strings.LastIndex(tok, ".")+1]
+		}(), `unsupported alg "none"`},
+		{"alg for other key type", signJWT(t, "RS256", "ec", keys.rsa, claims(nil)), "needs an RSA key"},
+		{"unknown kid", signJWT(t, "RS256", "retired", keys.rsa, claims(nil)), `unknown key id "retired"`},
+		{"signed by another key", signJWT(t, "ES256", "ec", mustECKey(t), claims(nil)), "bad signature"},
+		{"tampered claims", func() string {
+			parts := strings.Split(signJWT(t, "RS256", "rsa", keys.rsa, claims(nil)), ".")
+			forged, _ := json.Marshal(claims(func(c map[string]interface{}) { c["tenant"] = "globex" }))
+			parts[1] = base64.RawURLEncoding.EncodeToString(forged)
+			return strings.Join(parts, ".")
+		}(), "bad signature"},
+		{"malformed", "not-a-jwt", "malformed token"},
+		{"expired", signJWT(t, "RS256", "rsa", keys.rsa, claims(func(c map[string]interface{}) {
+			c["exp"] = jwtNow.Add(-time.Minute).Unix()
+		})), "token expired"},
+		{"expired within leeway", signJWT(t, "RS256", "rsa", keys.rsa, claims(func(c map[string]interface{}) {
+			c["exp"] = jwtNow.Add(-10 * time.Second).Unix()
+		})), ""},
+		{"no exp", signJWT(t, "RS256", "rsa", keys.rsa, claims(func(c map[string]interface{}) {
+			delete(c, "exp")
+		})), "no exp claim"},
+		{"not yet valid", signJWT(t, "RS256", "rsa", keys.rsa, claims(func(c map[string]interface{}) {
+			c["nbf"] = jwtNow.Add(time.Minute).Unix()
+		})), "not valid yet"},
+		{"not yet valid within leeway", signJWT(t, "RS256", "rsa", keys.rsa, claims(func(c map[string]interface{}) {
+			c["nbf"] = jwtNow.Add(10 * time.Second).Unix()
+		})), ""},
+		{"wrong issuer", signJWT(t, "RS256", "rsa", keys.rsa, claims(func(c map[string]interface{}) {
+			c["iss"] = "https://evil.example"
+		})), "unexpected issuer"},
+		{"no issuer", signJWT(t, "RS256", "rsa", keys.rsa, claims(func(c map[string]interface{}) {
+			delete(c, "iss")
+		})), "unexpected issuer"},
+		{"wrong audience", signJWT(t, "RS256", "rsa", keys.rsa, claims(func(c map[string]interface{}) {
+			c["aud"] = []string{"other"}
+		})), "unexpected audience"},
+		{"no sub", signJWT(t, "RS256", "rsa", keys.rsa, claims(func(c map[string]interface{}) {
+			delete(c, "sub")
+		})), "no sub claim"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := j.Authenticate(context.Background(), Credential{Scheme: SchemeBearer, Token: tt.token})
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrInvalidCredentials) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want ErrInvalidCredentials containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if id.Subject != "alice" || id.Tenant != "acme" || id.Method != MethodJWT {
+				t.Errorf("identity = %+v", id)
+			}
+			if len(id.Roles) != 1 || id.Roles[0] != "uploader" {
+				t.Errorf("roles = %v, want [uploader]", id.Roles)
+			}
+		})
+	}
+}
+
+func TestJWTIgnoresOtherSchemes(t *testing.T) {
+	j, _ := newTestJWT(t, JWTConfig{})
+	_, err := j.Authenticate(context.Background(), Credential{Scheme: SchemeAPIKey, Token: "k"})
+	if !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("err = %v, want ErrNoCredentials", err)
+	}
+}
+
+func mustECKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
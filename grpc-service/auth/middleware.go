@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Middleware rejects HTTP requests without valid credentials with 401 and
+// stores the caller's Identity in the request context. CORS preflight
+// requests must be answered before this middleware runs.
+func Middleware(a Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cred, ok := parseCredential(r.Header.Get("Authorization"), r.Header.Get("X-API-Key"))
+		if !ok {
+			unauthorized(w, ErrNoCredentials)
+			return
+		}
+		id, err := a.Authenticate(r.Context(), cred)
+		if err != nil {
+			slog.WarnContext(r.Context(), "auth: rejected request", "method", r.Method, "path", r.URL.Path, "error", err)
+			unauthorized(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), id)))
+	})
+}
+
+func unauthorized(w http.ResponseWriter, err error) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="pdf-extractor"`)
+	msg := "invalid credentials"
+	if errors.Is(err, ErrNoCredentials) {
+		msg = "credentials required"
+	}
+	http.Error(w, msg, http.StatusUnauthorized)
+}
+
+// authenticateGRPC reads credentials from incoming gRPC metadata.
+func authenticateGRPC(ctx context.Context, a Authenticator, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(k string) string {
+		if v := md.Get(k); len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+	cred, ok := parseCredential(first("authorization"), first("x-api-key"))
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "credentials required")
+	}
+	id, err := a.Authenticate(ctx, cred)
+	if err != nil {
+		slog.WarnContext(ctx, "auth: rejected call", "method", method, "error", err)
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+	return NewContext(ctx, id), nil
+}
+
+// UnaryServerInterceptor authenticates unary gRPC calls.
+func UnaryServerInterceptor(a Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticateGRPC(ctx, a, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor authenticates streaming gRPC calls.
+func StreamServerInterceptor(a Authenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticateGRPC(ss.Context(), a, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &identityStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// identityStream overrides the stream context with one carrying the Identity.
+type identityStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *identityStream) Context() context.Context { return s.ctx }
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Roles shipped in the example policy.
+const (
+	RoleUploader = "uploader"
+	RoleReviewer = "reviewer"
+	RoleAdmin    = "admin"
+	RoleConsumer = "consumer"
+)
+
+// Policy grants operations, named after ExtractorService methods, to roles.
+//
+// A caller's roles are those carried by its Identity plus any the policy
+// assigns to its subject; callers left with no roles, including anonymous
+// callers, get DefaultRoles. The operation "*" grants everything.
+type Policy struct {
+	Roles        map[string][]string `json:"roles"`
+	Subjects     map[string][]string `json:"subjects"`
+	DefaultRoles []string            `json:"default_roles"`
+}
+
+// LoadPolicy reads a JSON policy file.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("auth: read policy: %w", err)
+	}
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("auth: parse policy: %w", err)
+	}
+	for subject, roles := range p.Subjects {
+		for _, r := range roles {
+			if _, ok := p.Roles[r]; !ok {
+				return nil, fmt.Errorf("auth: policy: subject %q has undefined role %q", subject, r)
+			}
+		}
+	}
+	for _, r := range p.DefaultRoles {
+		if _, ok := p.Roles[r]; !ok {
+			return nil, fmt.Errorf("auth: policy: undefined default role %q", r)
+		}
+	}
+	return &p, nil
+}
+
+// RolesOf returns the roles the policy gives id, which may be nil.
+func (p *Policy) RolesOf(id *Identity) []string {
+	var roles []string
+	if id != nil {
+		roles = append(roles, id.Roles...)
+		roles = append(roles, p.Subjects[id.Subject]...)
+	}
+	if len(roles) == 0 {
+		roles = p.DefaultRoles
+	}
+	return roles
+}
+
+// Allowed reports whether id may perform op. id may be nil for an
+// unauthenticated caller.
+func (p *Policy) Allowed(id *Identity, op string) bool {
+	for _, r := range p.RolesOf(id) {
+		for _, granted := range p.Roles[r] {
+			if granted == "*" || granted == op {
+				return true
+			}
+		}
+	}
+	return false
+}
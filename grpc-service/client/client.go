@@ -0,0 +1,245 @@
+// Package client is the Go SDK for the PDF extractor service. It speaks to
+// grpc-service over either gRPC (port 50051) or the HTTP/JSON REST API
+// (port 8080) behind one Client type.
+//
+//	c, err := client.DialGRPC("localhost:50051")
+//	...
+//	up, err := c.UploadFile(ctx, "invoice.pdf", []string{"invoice_total"})
+//	res, err := c.Wait(ctx, up.DocumentId, client.WaitOptions{})
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/pb"
+)
+
+// Document statuses after which results no longer change without human or
+// operator action.
+var terminalStatuses = map[string]bool{
+	"completed":    true,
+	"partial":      true,
+	"failed":       true,
+	"needs_review": true,
+}
+
+// transport is implemented once per wire protocol.
+type transport interface {
+	upload(ctx context.Context, req *pb.UploadDocumentRequest) (*pb.UploadDocumentResponse, error)
+	getDataPoints(ctx context.Context, id string) (*pb.GetDataPointsResponse, error)
+	listDocuments(ctx context.Context, req *pb.ListDocumentsRequest) (*pb.ListDocumentsResponse, error)
+	deleteDocument(ctx context.Context, id string) error
+	reprocess(ctx context.Context, req *pb.ReprocessDocumentRequest) (*pb.ReprocessDocumentResponse, error)
+	close() error
+}
+
+// Client calls the extractor service. It is safe for concurrent use.
+type Client struct {
+	t transport
+}
+
+// NewGRPC returns a Client that uses an existing gRPC connection. The caller
+// keeps ownership of conn.
+func NewGRPC(conn grpc.ClientConnInterface) *Client {
+	return &Client{t: &grpcTransport{c: pb.NewExtractorServiceClient(conn)}}
+}
+
+// DialGRPC connects to the gRPC listener at target (e.g. "localhost:50051").
+// The connection is plaintext unless opts supply transport credentials; add
+// grpc.WithPerRPCCredentials(Credentials{...}) to authenticate. Close
+// releases the connection.
+func DialGRPC(target string, opts ...grpc.DialOption) (*Client, error) {
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
+	conn, err := grpc.Dial(target, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("client: dial %s: %w", target, err)
+	}
+	return &Client{t: &grpcTransport{c: pb.NewExtractorServiceClient(conn), conn: conn}}, nil
+}
+
+// NewHTTP returns a Client for the REST API at baseURL
+// (e.g. "http://localhost:8080"). httpClient may be nil to use
+// http.DefaultClient; wrap it with Credentials.HTTPClient to authenticate.
+func NewHTTP(baseURL string, httpClient HTTPDoer) *Client {
+	return &Client{t: newHTTPTransport(baseURL, httpClient)}
+}
+
+// Close releases any connection the Client owns.
+func (c *Client) Close() error {
+	return c.t.close()
+}
+
+// Upload sends the PDF read from r and returns the new document's ID and
+// initial status.
+func (c *Client) Upload(ctx context.Context, filename string, r io.Reader, dataPoints []string) (*pb.UploadDocumentResponse, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("client: read %s: %w", filename, err)
+	}
+	resp, err := c.t.upload(ctx, &pb.UploadDocumentRequest{Filename: filename, PdfData: data, DataPoints: dataPoints})
+	if err != nil {
+		return nil, wrap("upload", err)
+	}
+	return resp, nil
+}
+
+// UploadFile uploads the PDF at path under its base name.
+func (c *Client) UploadFile(ctx context.Context, path string, dataPoints []string) (*pb.UploadDocumentResponse, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("client: %w", err)
+	}
+	defer f.Close()
+	return c.Upload(ctx, filepath.Base(path), f, dataPoints)
+}
+
+// Results returns the current extraction results for a document.
+func (c *Client) Results(ctx context.Context, documentID string) (*pb.GetDataPointsResponse, error) {
+	resp, err := c.t.getDataPoints(ctx, documentID)
+	if err != nil {
+		return nil, wrap("get results", err)
+	}
+	return resp, nil
+}
+
+// DownloadResults writes a document's current results to w as indented JSON.
+func (c *Client) DownloadResults(ctx context.Context, documentID string, w io.Writer) error {
+	res, err := c.Results(ctx, documentID)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(res); err != nil {
+		return fmt.Errorf("client: write results: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a document and its results.
+func (c *Client) Delete(ctx context.Context, documentID string) error {
+	if err := c.t.deleteDocument(ctx, documentID); err != nil {
+		return wrap("delete", err)
+	}
+	return nil
+}
+
+// Reprocess sends a document through extraction again, optionally with a new
+// set of data points (nil keeps the current ones).
+func (c *Client) Reprocess(ctx context.Context, documentID string, dataPoints []string) (*pb.ReprocessDocumentResponse, error) {
+	resp, err := c.t.reprocess(ctx, &pb.ReprocessDocumentRequest{DocumentId: documentID, DataPoints: dataPoints})
+	if err != nil {
+		return nil, wrap("reprocess", err)
+	}
+	return resp, nil
+}
+
+// WaitOptions tunes Wait.
+type WaitOptions struct {
+	// PollInterval is the delay between status checks; defaults to 2s.
+	PollInterval time.Duration
+}
+
+// Wait polls a document until it reaches a terminal status (completed,
+// partial, failed or needs_review) and returns its results. Bound the wait
+// with a context deadline; when it expires Wait returns the context's error.
+func (c *Client) Wait(ctx context.Context, documentID string, opts WaitOptions) (*pb.GetDataPointsResponse, error) {
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		res, err := c.Results(ctx, documentID)
+		if err != nil {
+			return nil, err
+		}
+		if terminalStatuses[res.Status] {
+			return res, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, wrap("wait", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// ListOptions filters and pages ListDocuments.
+type ListOptions struct {
+	// Status keeps only documents in this status.
+	Status string
+	// PageSize is the number of documents fetched per request; defaults to 100.
+	PageSize int32
+}
+
+// Documents returns an iterator over every document matching opts, fetching
+// pages lazily:
+//
+//	it := c.Documents(ctx, client.ListOptions{Status: "failed"})
+//	for it.Next() {
+//		fmt.Println(it.Document().DocumentId)
+//	}
+//	if err := it.Err(); err != nil { ... }
+func (c *Client) Documents(ctx context.Context, opts ListOptions) *DocumentIterator {
+	if opts.PageSize <= 0 {
+		opts.PageSize = 100
+	}
+	return &DocumentIterator{ctx: ctx, c: c, opts: opts}
+}
+
+// DocumentIterator walks the pages of a document listing.
+type DocumentIterator struct {
+	ctx   context.Context
+	c     *Client
+	opts  ListOptions
+	page  []*pb.DocumentSummary
+	token string
+	cur   *pb.DocumentSummary
+	done  bool
+	err   error
+}
+
+// Next advances to the next document, fetching another page if needed. It
+// returns false when the listing is exhausted or an error occurred.
+func (it *DocumentIterator) Next() bool {
+	for len(it.page) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+		resp, err := it.c.t.listDocuments(it.ctx, &pb.ListDocumentsRequest{
+			PageSize:  it.opts.PageSize,
+			PageToken: it.token,
+			Status:    it.opts.Status,
+		})
+		if err != nil {
+			it.err = wrap("list documents", err)
+			return false
+		}
+		it.page, it.token = resp.Documents, resp.NextPageToken
+		it.done = resp.NextPageToken == ""
+	}
+	it.cur, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Document returns the document Next advanced to.
+func (it *DocumentIterator) Document() *pb.DocumentSummary {
+	return it.cur
+}
+
+// Err returns the error that stopped iteration, if any.
+func (it *DocumentIterator) Err() error {
+	return it.err
+}
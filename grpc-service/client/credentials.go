@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Credentials authenticate every request a Client makes. Set exactly one
+// field.
+type Credentials struct {
+	APIKey      string
+	BearerToken string
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials, so
+// Credentials can be passed to grpc.WithPerRPCCredentials.
+func (c Credentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	if c.APIKey != "" {
+		return map[string]string{"x-api-key": c.APIKey}, nil
+	}
+	if c.BearerToken != "" {
+		return map[string]string{"authorization": "Bearer " + c.BearerToken}, nil
+	}
+	return nil, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials. It
+// returns false so credentials also work against plaintext development
+// listeners.
+func (c Credentials) RequireTransportSecurity() bool { return false }
+
+// HTTPClient returns an HTTPDoer for NewHTTP that adds the credentials to
+// every request sent through hc (nil means http.DefaultClient).
+func (c Credentials) HTTPClient(hc HTTPDoer) HTTPDoer {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &credentialsDoer{creds: c, next: hc}
+}
+
+type credentialsDoer struct {
+	creds Credentials
+	next  HTTPDoer
+}
+
+func (d *credentialsDoer) Do(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	if d.creds.APIKey != "" {
+		req.Header.Set("X-API-Key", d.creds.APIKey)
+	} else if d.creds.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+d.creds.BearerToken)
+	}
+	return d.next.Do(req)
+}
@@ -0,0 +1,168 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Sentinel errors classifying every failure returned by Client, whichever
+// transport is in use. Test with errors.Is.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrInvalid      = errors.New("invalid argument")
+	ErrConflict     = errors.New("conflict")
+	ErrUnavailable  = errors.New("service unavailable")
+	ErrTimeout      = errors.New("deadline exceeded")
+	ErrCanceled     = errors.New("canceled")
+	ErrServer       = errors.New("server error")
+	ErrUnauthorized = errors.New("unauthenticated")
+	ErrForbidden    = errors.New("permission denied")
+	ErrRateLimited  = errors.New("rate limited")
+	errUnclassified = errors.New("request failed")
+)
+
+// Error is returned by every Client call that reached (or tried to reach)
+// the service.
+type Error struct {
+	// Op is the client operation, e.g. "upload".
+	Op string
+	// Kind is one of the sentinel errors above.
+	Kind error
+	// Message is the server's explanation, when there is one.
+	Message string
+	// Err is the underlying transport error.
+	Err error
+	// RetryAfter is how long the server asked the caller to back off, for
+	// ErrRateLimited; zero if it gave no hint.
+	RetryAfter time.Duration
+}
+
+func (e *Error) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("client: %s: %v: %s", e.Op, e.Kind, e.Message)
+	}
+	return fmt.Sprintf("client: %s: %v", e.Op, e.Kind)
+}
+
+// Unwrap exposes both the classification and the underlying error.
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// httpError is produced by the REST transport for non-2xx responses.
+type httpError struct {
+	code       int
+	body       string
+	retryAfter time.Duration
+}
+
+func (e *httpError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.code, e.body)
+}
+
+// retryHintError carries the gRPC "retry-after" response header alongside
+// the status error it came with.
+type retryHintError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *retryHintError) Error() string { return e.err.Error() }
+func (e *retryHintError) Unwrap() error { return e.err }
+
+// withRetryHint attaches a "retry-after" header (in seconds) from md to err.
+func withRetryHint(err error, md metadata.MD) error {
+	if err == nil {
+		return nil
+	}
+	if v := md.Get("retry-after"); len(v) > 0 {
+		if secs, perr := strconv.Atoi(v[0]); perr == nil {
+			return &retryHintError{err: err, after: time.Duration(secs) * time.Second}
+		}
+	}
+	return err
+}
+
+// wrap classifies err from either transport into an *Error.
+func wrap(op string, err error) error {
+	e := &Error{Op: op, Kind: errUnclassified, Err: err}
+
+	var hint *retryHintError
+	if errors.As(err, &hint) {
+		e.RetryAfter = hint.after
+		err = hint.err
+	}
+
+	var he *httpError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		e.Kind = ErrTimeout
+	case errors.Is(err, context.Canceled):
+		e.Kind = ErrCanceled
+	case errors.As(err, &he):
+		e.Message = he.body
+		switch {
+		case he.code == http.StatusNotFound:
+			e.Kind = ErrNotFound
+		case he.code == http.StatusBadRequest:
+			e.Kind = ErrInvalid
+		case he.code == http.StatusConflict:
+			e.Kind = ErrConflict
+		case he.code == http.StatusUnauthorized:
+			e.Kind = ErrUnauthorized
+		case he.code == http.StatusForbidden:
+			e.Kind = ErrForbidden
+		case he.code == http.StatusTooManyRequests:
+			e.Kind = ErrRateLimited
+			e.RetryAfter = he.retryAfter
+		case he.code == http.StatusServiceUnavailable, he.code == http.StatusBadGateway:
+			e.Kind = ErrUnavailable
+		case he.code >= 500:
+			e.Kind = ErrServer
+		}
+	default:
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			e.Kind = ErrUnavailable
+			break
+		}
+		st, ok := status.FromError(err)
+		if !ok {
+			e.Message = err.Error()
+			break
+		}
+		e.Message = st.Message()
+		switch st.Code() {
+		case codes.NotFound:
+			e.Kind = ErrNotFound
+		case codes.InvalidArgument:
+			e.Kind = ErrInvalid
+		case codes.FailedPrecondition, codes.AlreadyExists, codes.Aborted:
+			e.Kind = ErrConflict
+		case codes.Unauthenticated:
+			e.Kind = ErrUnauthorized
+		case codes.PermissionDenied:
+			e.Kind = ErrForbidden
+		case codes.ResourceExhausted:
+			e.Kind = ErrRateLimited
+		case codes.Unavailable:
+			e.Kind = ErrUnavailable
+		case codes.DeadlineExceeded:
+			e.Kind = ErrTimeout
+		case codes.Canceled:
+			e.Kind = ErrCanceled
+		case codes.Internal, codes.Unknown, codes.DataLoss:
+			e.Kind = ErrServer
+		}
+	}
+	return e
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/pb"
+)
+
+// grpcTransport calls ExtractorService over gRPC. Every call captures the
+// response header so a "retry-after" hint reaches the caller's *Error.
+type grpcTransport struct {
+	c    pb.ExtractorServiceClient
+	conn *grpc.ClientConn // nil when the caller owns the connection
+}
+
+func (t *grpcTransport) upload(ctx context.Context, req *pb.UploadDocumentRequest) (*pb.UploadDocumentResponse, error) {
+	var md metadata.MD
+	resp, err := t.c.UploadDocument(ctx, req, grpc.Header(&md))
+	return resp, withRetryHint(err, md)
+}
+
+func (t *grpcTransport) getDataPoints(ctx context.Context, id string) (*pb.GetDataPointsResponse, error) {
+	var md metadata.MD
+	resp, err := t.c.GetDataPoints(ctx, &pb.GetDataPointsRequest{DocumentId: id}, grpc.Header(&md))
+	return resp, withRetryHint(err, md)
+}
+
+func (t *grpcTransport) listDocuments(ctx context.Context, req *pb.ListDocumentsRequest) (*pb.ListDocumentsResponse, error) {
+	var md metadata.MD
+	resp, err := t.c.ListDocuments(ctx, req, grpc.Header(&md))
+	return resp, withRetryHint(err, md)
+}
+
+func (t *grpcTransport) deleteDocument(ctx context.Context, id string) error {
+	var md metadata.MD
+	_, err := t.c.DeleteDocument(ctx, &pb.DeleteDocumentRequest{DocumentId: id}, grpc.Header(&md))
+	return withRetryHint(err, md)
+}
+
+func (t *grpcTransport) reprocess(ctx context.Context, req *pb.ReprocessDocumentRequest) (*pb.ReprocessDocumentResponse, error) {
+	var md metadata.MD
+	resp, err := t.c.ReprocessDocument(ctx, req, grpc.Header(&md))
+	return resp, withRetryHint(err, md)
+}
+
+func (t *grpcTransport) close() error {
+	if t.conn == nil {
+		return nil
+	}
+	return t.conn.Close()
+}
@@ -0,0 +1,149 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/pb"
+)
+
+// HTTPDoer is the subset of *http.Client the REST transport needs.
+type HTTPDoer interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// httpTransport calls the REST API served on the HTTP port.
+type httpTransport struct {
+	base string
+	hc   HTTPDoer
+}
+
+func newHTTPTransport(baseURL string, hc HTTPDoer) *httpTransport {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &httpTransport{base: strings.TrimRight(baseURL, "/"), hc: hc}
+}
+
+func (t *httpTransport) upload(ctx context.Context, req *pb.UploadDocumentRequest) (*pb.UploadDocumentResponse, error) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("file", req.Filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fw.Write(req.PdfData); err != nil {
+		return nil, err
+	}
+	dp, err := json.Marshal(req.DataPoints)
+	if err != nil {
+		return nil, err
+	}
+	if err := mw.WriteField("data_points", string(dp)); err != nil {
+		return nil, err
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var resp pb.UploadDocumentResponse
+	if err := t.do(ctx, http.MethodPost, "/documents", mw.FormDataContentType(), &body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (t *httpTransport) getDataPoints(ctx context.Context, id string) (*pb.GetDataPointsResponse, error) {
+	var resp pb.GetDataPointsResponse
+	if err := t.do(ctx, http.MethodGet, "/documents/"+url.PathEscape(id)+"/datapoints", "", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (t *httpTransport) listDocuments(ctx context.Context, req *pb.ListDocumentsRequest) (*pb.ListDocumentsResponse, error) {
+	q := url.Values{}
+	if req.PageSize > 0 {
+		q.Set("page_size", strconv.Itoa(int(req.PageSize)))
+	}
+	if req.PageToken != "" {
+		q.Set("page_token", req.PageToken)
+	}
+	if req.Status != "" {
+		q.Set("status", req.Status)
+	}
+	path := "/documents"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	var resp pb.ListDocumentsResponse
+	if err := t.do(ctx, http.MethodGet, path, "", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (t *httpTransport) deleteDocument(ctx context.Context, id string) error {
+	var resp pb.DeleteDocumentResponse
+	return t.do(ctx, http.MethodDelete, "/documents/"+url.PathEscape(id), "", nil, &resp)
+}
+
+func (t *httpTransport) reprocess(ctx context.Context, req *pb.ReprocessDocumentRequest) (*pb.ReprocessDocumentResponse, error) {
+	body, err := json.Marshal(struct {
+		DataPoints []string `json:"data_points,omitempty"`
+	}{req.DataPoints})
+	if err != nil {
+		return nil, err
+	}
+	var resp pb.ReprocessDocumentResponse
+	if err := t.do(ctx, http.MethodPost, "/documents/"+url.PathEscape(req.DocumentId)+"/reprocess", "application/json", bytes.NewReader(body), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (t *httpTransport) close() error { return nil }
+
+// do sends one request and decodes a JSON response into out. Non-2xx
+// responses become *httpError.
+func (t *httpTransport) do(ctx context.Context, method, path, contentType string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, t.base+path, body)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := t.hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		he := &httpError{code: resp.StatusCode, body: strings.TrimSpace(string(data))}
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			he.retryAfter = time.Duration(secs) * time.Second
+		}
+		return he
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/client"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/pb"
+)
+
+// newFlags returns a FlagSet for a subcommand whose parse errors surface as
+// errUsage.
+func newFlags(name, argsUsage string) *flag.FlagSet {
+	fs := flag.NewFlagSet("pdfx "+name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: pdfx %s [flags] %s\n", name, argsUsage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func parse(fs *flag.FlagSet, args []string, minArgs int) error {
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+	if fs.NArg() < minArgs {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// dataPointsFlag parses a comma-separated list of data point names.
+type dataPointsFlag []string
+
+func (d *dataPointsFlag) String() string { return strings.Join(*d, ",") }
+
+func (d *dataPointsFlag) Set(v string) error {
+	for _, p := range strings.Split(v, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			*d = append(*d, p)
+		}
+	}
+	return nil
+}
+
+// loadTemplate reads data point names from a JSON file holding either an
+// array of names or an object with a "data_points" array.
+func loadTemplate(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err == nil {
+		return names, nil
+	}
+	var obj struct {
+		DataPoints []string `json:"data_points"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("template %s: want a JSON array or {\"data_points\": [...]}", path)
+	}
+	return obj.DataPoints, nil
+}
+
+func runUpload(ctx context.Context, c *client.Client, args []string) error {
+	fs := newFlags("upload", "FILE|GLOB...")
+	var dataPoints dataPointsFlag
+	fs.Var(&dataPoints, "data-points", "comma-separated data points to extract (repeatable)")
+	template := fs.String("template", "", "JSON file listing data points to extract")
+	wait := fs.Bool("wait", false, "wait for extraction to finish and print the results")
+	timeout := fs.Duration("timeout", 5*time.Minute, "how long --wait waits per document")
+	asJSON := fs.Bool("json", false, "with --wait, print results as JSON")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+
+	if *template != "" {
+		names, err := loadTemplate(*template)
+		if err != nil {
+			return err
+		}
+		dataPoints = append(dataPoints, names...)
+	}
+	if len(dataPoints) == 0 {
+		return errors.New("no data points: use --data-points or --template")
+	}
+
+	var files []string
+	for _, pattern := range fs.Args() {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("%s: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return fmt.Errorf("%s: no such file", pattern)
+		}
+		files = append(files, matches...)
+	}
+
+	var failed int
+	for _, path := range files {
+		up, err := c.UploadFile(ctx, path, dataPoints)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s\t%s\t%s\n", up.DocumentId, up.Status, path)
+		if !*wait {
+			continue
+		}
+
+		wctx, cancel := context.WithTimeout(ctx, *timeout)
+		res, err := c.Wait(wctx, up.DocumentId, client.WaitOptions{})
+		cancel()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			failed++
+			continue
+		}
+		if err := printResults(os.Stdout, res, *asJSON); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed", failed, len(files))
+	}
+	return nil
+}
+
+func runStatus(ctx context.Context, c *client.Client, args []string) error {
+	fs := newFlags("status", "DOCUMENT_ID...")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DOCUMENT ID\tSTATUS\tREVISION")
+	var firstErr error
+	for _, id := range fs.Args() {
+		res, err := c.Results(ctx, id)
+		if err != nil {
+			fmt.Fprintf(tw, "%s\terror: %v\t\n", id, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", res.DocumentId, res.Status, res.Revision)
+	}
+	tw.Flush()
+	return firstErr
+}
+
+func runGet(ctx context.Context, c *client.Client, args []string) error {
+	fs := newFlags("get", "DOCUMENT_ID")
+	asJSON := fs.Bool("json", false, "print results as JSON")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	res, err := c.Results(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return printResults(os.Stdout, res, *asJSON)
+}
+
+// printResults writes a document's results as a table or as JSON.
+func printResults(w io.Writer, res *pb.GetDataPointsResponse, asJSON bool) error {
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+
+	fmt.Fprintf(w, "document %s: %s (revision %d)\n", res.DocumentId, res.Status, res.Revision)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATA POINT\tVALUE\tSTATUS\tCONFIDENCE\tPAGE")
+	for _, dp := range sortedKeys(res.Results) {
+		status, confidence, page := "", "", ""
+		if d := res.Details[dp]; d != nil {
+			status = d.Status
+			if d.Page > 0 {
+				confidence = strconv.FormatFloat(d.Confidence, 'f', 2, 64)
+				page = strconv.Itoa(int(d.Page))
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", dp, res.Results[dp], status, confidence, page)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, v := range res.Violations {
+		fmt.Fprintf(w, "violation: %s\n", v.Message)
+	}
+	for _, r := range res.ReviewReasons {
+		fmt.Fprintf(w, "needs review: %s\n", r)
+	}
+	return nil
+}
+
+func runList(ctx context.Context, c *client.Client, args []string) error {
+	fs := newFlags("list", "")
+	status := fs.String("status", "", "only documents in this status")
+	asJSON := fs.Bool("json", false, "print one JSON object per line")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	enc := json.NewEncoder(os.Stdout)
+	if !*asJSON {
+		fmt.Fprintln(tw, "DOCUMENT ID\tFILENAME\tSTATUS")
+	}
+	it := c.Documents(ctx, client.ListOptions{Status: *status})
+	for it.Next() {
+		d := it.Document()
+		if *asJSON {
+			if err := enc.Encode(d); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", d.DocumentId, d.Filename, d.Status)
+	}
+	tw.Flush()
+	return it.Err()
+}
+
+func runExport(ctx context.Context, c *client.Client, args []string) error {
+	fs := newFlags("export", "[DOCUMENT_ID...]")
+	status := fs.String("status", "", "export every document in this status (when no IDs are given)")
+	format := fs.String("format", "csv", "csv (one row per data point) or jsonl (one document per line)")
+	out := fs.String("o", "-", "output file, - for stdout")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *format != "csv" && *format != "jsonl" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	ids := fs.Args()
+	if len(ids) == 0 {
+		it := c.Documents(ctx, client.ListOptions{Status: *status})
+		for it.Next() {
+			ids = append(ids, it.Document().DocumentId)
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+	}
+
+	w := io.Writer(os.Stdout)
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	cw := csv.NewWriter(w)
+	enc := json.NewEncoder(w)
+	if *format == "csv" {
+		_ = cw.Write([]string{"document_id", "document_status", "data_point", "value", "status", "confidence", "page"})
+	}
+	for _, id := range ids {
+		res, err := c.Results(ctx, id)
+		if err != nil {
+			return err
+		}
+		if *format == "jsonl" {
+			if err := enc.Encode(res); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, dp := range sortedKeys(res.Results) {
+			row := []string{res.DocumentId, res.Status, dp, res.Results[dp], "", "", ""}
+			if d := res.Details[dp]; d != nil {
+				row[4] = d.Status
+				row[5] = strconv.FormatFloat(d.Confidence, 'f', 2, 64)
+				row[6] = strconv.Itoa(int(d.Page))
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	if *out != "-" {
+		fmt.Fprintf(os.Stderr, "exported %d documents to %s\n", len(ids), *out)
+	}
+	return nil
+}
+
+func runReprocess(ctx context.Context, c *client.Client, args []string) error {
+	fs := newFlags("reprocess", "DOCUMENT_ID...")
+	var dataPoints dataPointsFlag
+	fs.Var(&dataPoints, "data-points", "replace the documents' data points (comma-separated)")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	return forEach(fs.Args(), func(id string) error {
+		resp, err := c.Reprocess(ctx, id, dataPoints)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\t%s\n", resp.DocumentId, resp.Status)
+		return nil
+	})
+}
+
+func runDelete(ctx context.Context, c *client.Client, args []string) error {
+	fs := newFlags("delete", "DOCUMENT_ID...")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	return forEach(fs.Args(), func(id string) error {
+		if err := c.Delete(ctx, id); err != nil {
+			return err
+		}
+		fmt.Printf("%s\tdeleted\n", id)
+		return nil
+	})
+}
+
+// forEach runs fn for every ID, reporting failures as it goes, and returns an
+// error if any call failed.
+func forEach(ids []string, fn func(id string) error) error {
+	var failed int
+	for _, id := range ids {
+		if err := fn(id); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", id, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d documents failed", failed, len(ids))
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,163 @@
+// Command pdfx is a command-line client for the PDF extractor service.
+//
+//	pdfx [--server ADDR] <command> [flags] [args]
+//
+// ADDR is a gRPC target such as "localhost:50051" or, when it starts with
+// http:// or https://, the base URL of the REST API. It defaults to
+// $PDFX_SERVER, then "localhost:50051". Credentials come from --api-key or
+// --token ($PDFX_API_KEY, $PDFX_TOKEN). --tls switches gRPC to TLS; --ca-file,
+// --cert and --key ($PDFX_CA_FILE, $PDFX_CERT_FILE, $PDFX_KEY_FILE) set the
+// trusted CAs and a client certificate for mutual TLS on either transport.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/client"
+)
+
+// command is one pdfx subcommand.
+type command struct {
+	summary string
+	run     func(ctx context.Context, c *client.Client, args []string) error
+}
+
+var commands = map[string]command{
+	"upload":    {"upload PDFs (files or globs) for extraction", runUpload},
+	"status":    {"print the status of documents", runStatus},
+	"get":       {"print the extraction results of a document", runGet},
+	"list":      {"list documents", runList},
+	"export":    {"export results of many documents as CSV or JSON lines", runExport},
+	"reprocess": {"run extraction again for documents", runReprocess},
+	"delete":    {"delete documents", runDelete},
+}
+
+// errUsage makes main exit with status 2 without printing anything else.
+var errUsage = errors.New("usage")
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: pdfx [--server ADDR] <command> [flags] [args]\n\ncommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun 'pdfx <command> -h' for command flags.\n")
+}
+
+func main() {
+	server := os.Getenv("PDFX_SERVER")
+	if server == "" {
+		server = "localhost:50051"
+	}
+	global := flag.NewFlagSet("pdfx", flag.ExitOnError)
+	global.Usage = usage
+	global.StringVar(&server, "server", server, "gRPC target or REST base URL")
+	creds := client.Credentials{APIKey: os.Getenv("PDFX_API_KEY"), BearerToken: os.Getenv("PDFX_TOKEN")}
+	global.StringVar(&creds.APIKey, "api-key", creds.APIKey, "API key (default $PDFX_API_KEY)")
+	global.StringVar(&creds.BearerToken, "token", creds.BearerToken, "JWT bearer token (default $PDFX_TOKEN)")
+	var tlsOpts tlsOptions
+	global.BoolVar(&tlsOpts.enabled, "tls", os.Getenv("PDFX_TLS") != "", "use TLS for gRPC (default true if $PDFX_TLS is set)")
+	global.StringVar(&tlsOpts.caFile, "ca-file", os.Getenv("PDFX_CA_FILE"), "PEM CA bundle to trust (default $PDFX_CA_FILE)")
+	global.StringVar(&tlsOpts.certFile, "cert", os.Getenv("PDFX_CERT_FILE"), "client certificate for mutual TLS (default $PDFX_CERT_FILE)")
+	global.StringVar(&tlsOpts.keyFile, "key", os.Getenv("PDFX_KEY_FILE"), "client key for mutual TLS (default $PDFX_KEY_FILE)")
+	_ = global.Parse(os.Args[1:])
+
+	args := global.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "pdfx: unknown command %q\n\n", args[0])
+		usage()
+		os.Exit(2)
+	}
+
+	c, err := dial(server, creds, tlsOpts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "pdfx: %v\n", err)
+		os.Exit(1)
+	}
+	defer c.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := cmd.run(ctx, c, args[1:]); err != nil {
+		if errors.Is(err, errUsage) {
+			os.Exit(2)
+		}
+		fmt.Fprintf(os.Stderr, "pdfx %s: %v\n", args[0], err)
+		os.Exit(1)
+	}
+}
+
+// tlsOptions are the global TLS flags.
+type tlsOptions struct {
+	enabled                   bool
+	caFile, certFile, keyFile string
+}
+
+// config returns the client TLS configuration, or nil when no TLS flag is
+// set.
+func (o tlsOptions) config() (*tls.Config, error) {
+	if !o.enabled && o.caFile == "" && o.certFile == "" {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if o.caFile != "" {
+		pem, err := os.ReadFile(o.caFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", o.caFile)
+		}
+	}
+	if o.certFile != "" || o.keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.certFile, o.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+func dial(server string, creds client.Credentials, tlsOpts tlsOptions) (*client.Client, error) {
+	tlsConfig, err := tlsOpts.config()
+	if err != nil {
+		return nil, fmt.Errorf("tls: %w", err)
+	}
+	if strings.HasPrefix(server, "http://") || strings.HasPrefix(server, "https://") {
+		var hc client.HTTPDoer
+		if tlsConfig != nil {
+			hc = &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+		}
+		return client.NewHTTP(server, creds.HTTPClient(hc)), nil
+	}
+	opts := []grpc.DialOption{grpc.WithPerRPCCredentials(creds)}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	}
+	return client.DialGRPC(server, opts...)
+}
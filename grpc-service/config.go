@@ -0,0 +1,238 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/config"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/logging"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/tracing"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/tlsutil"
+)
+
+// Config is the grpc-service configuration. See package config for how it
+// is loaded; defaultConfig holds the defaults.
+type Config struct {
+	GRPCPort        string        `key:"grpc_port" env:"GRPC_PORT" usage:"gRPC listen port"`
+	HTTPPort        string        `key:"http_port" env:"HTTP_PORT" usage:"REST listen port"`
+	ShutdownTimeout time.Duration `key:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" usage:"how long in-flight requests may run after SIGTERM"`
+	UploadMemory    int64         `key:"upload_memory" env:"UPLOAD_MAX_MEMORY" usage:"bytes of a multipart upload held in memory; the rest spills to disk"`
+	HealthTimeout   time.Duration `key:"health_timeout" env:"HEALTH_CHECK_TIMEOUT" usage:"time each /readyz dependency check may take"`
+
+	Kafka      config.Kafka     `key:"kafka"`
+	Review     ReviewConfig     `key:"review"`
+	Validation ValidationConfig `key:"validation"`
+	Auth       AuthConfig       `key:"auth"`
+	Callback   CallbackConfig   `key:"callback"`
+	RateLimit  RateLimitConfig  `key:"rate_limit"`
+	Admission  AdmissionConfig  `key:"admission"`
+	Usage      UsageConfig      `key:"usage"`
+	Sweeper    SweeperConfig    `key:"sweeper"`
+	TLS        TLSConfig        `key:"tls"`
+	Tracing    tracing.Config   `key:"tracing"`
+	Logging    logging.Config   `key:"logging"`
+}
+
+// ReviewConfig decides which results need a human.
+type ReviewConfig struct {
+	MinConfidence            float64            `key:"min_confidence" env:"REVIEW_MIN_CONFIDENCE" usage:"found values below this confidence go to review; 0 disables"`
+	MinConfidenceByDataPoint map[string]float64 `key:"min_confidence_by_data_point" env:"REVIEW_MIN_CONFIDENCE_BY_DATA_POINT" usage:"per-data-point overrides, e.g. invoice_total=0.9"`
+}
+
+// ValidationConfig locates the validation rules.
+type ValidationConfig struct {
+	RulesFile string `key:"rules_file" env:"VALIDATION_RULES_FILE" usage:"JSON validation rules file"`
+}
+
+// AuthConfig configures caller authentication and the role policy.
+type AuthConfig struct {
+	APIKeysFile    string        `key:"api_keys_file" env:"AUTH_API_KEYS_FILE" usage:"JSON file of hashed API keys"`
+	JWKSFile       string        `key:"jwks_file" env:"AUTH_JWKS_FILE" usage:"JWKS used to verify JWT bearer tokens"`
+	JWTIssuer      string        `key:"jwt_issuer" env:"AUTH_JWT_ISSUER" usage:"required iss claim"`
+	JWTAudience    string        `key:"jwt_audience" env:"AUTH_JWT_AUDIENCE" usage:"required aud claim"`
+	JWTLeeway      time.Duration `key:"jwt_leeway" env:"AUTH_JWT_LEEWAY" usage:"clock skew tolerated on exp and nbf"`
+	JWTTenantClaim string        `key:"jwt_tenant_claim" env:"AUTH_JWT_TENANT_CLAIM" usage:"JWT claim holding the caller's tenant"`
+	JWTRolesClaim  string        `key:"jwt_roles_claim" env:"AUTH_JWT_ROLES_CLAIM" usage:"JWT claim holding the caller's roles"`
+	PolicyFile     string        `key:"policy_file" env:"AUTH_POLICY_FILE" usage:"JSON role policy; unset allows every caller every method except the operator ones"`
+}
+
+// CallbackConfig verifies the consumer's signed result callbacks.
+type CallbackConfig struct {
+	HMACSecret string        `key:"hmac_secret" env:"CALLBACK_HMAC_SECRET" secret:"true" usage:"shared secret for result callbacks; unset rejects them all"`
+	MaxSkew    time.Duration `key:"max_skew" env:"CALLBACK_MAX_SKEW" usage:"accepted clock difference for signed callbacks"`
+}
+
+// RateLimitConfig sets the token buckets; a zero rate disables one and a
+// zero burst means the rate rounded up.
+type RateLimitConfig struct {
+	RPS         float64 `key:"rps" env:"RATE_LIMIT_RPS" usage:"requests per second per caller"`
+	Burst       int     `key:"burst" env:"RATE_LIMIT_BURST" usage:"burst per caller"`
+	TenantRPS   float64 `key:"tenant_rps" env:"RATE_LIMIT_TENANT_RPS" usage:"requests per second per tenant"`
+	TenantBurst int     `key:"tenant_burst" env:"RATE_LIMIT_TENANT_BURST" usage:"burst per tenant"`
+}
+
+// AdmissionConfig refuses uploads while the pipeline is backed up; zero
+// limits are disabled.
+type AdmissionConfig struct {
+	MaxPending  int           `key:"max_pending" env:"ADMISSION_MAX_PENDING" usage:"refuse uploads once this many documents are pending or processing"`
+	MaxLag      int64         `key:"max_lag" env:"ADMISSION_MAX_LAG" usage:"refuse uploads while consumer lag exceeds this many events"`
+	LagInterval time.Duration `key:"lag_interval" env:"ADMISSION_LAG_INTERVAL" usage:"how often consumer lag is measured"`
+	RetryAfter  time.Duration `key:"retry_after" env:"ADMISSION_RETRY_AFTER" usage:"back-off suggested to refused uploads"`
+}
+
+// UsageConfig locates the tenant quotas.
+type UsageConfig struct {
+	QuotasFile string `key:"quotas_file" env:"USAGE_QUOTAS_FILE" usage:"JSON monthly quotas per tenant"`
+}
+
+// SweeperConfig finds documents stuck in the pipeline.
+type SweeperConfig struct {
+	SLA          time.Duration `key:"sla" env:"SWEEPER_SLA" usage:"how long a pending or processing document may go without progress; 0 disables the sweeper"`
+	Interval     time.Duration `key:"interval" env:"SWEEPER_INTERVAL" usage:"how often documents are checked"`
+	MaxRepublish int           `key:"max_republish" env:"SWEEPER_MAX_REPUBLISH" usage:"times a stuck document is republished before it is marked failed"`
+}
+
+// TLSConfig serves both listeners over TLS when CertFile is set.
+type TLSConfig struct {
+	CertFile       string `key:"cert_file" env:"TLS_CERT_FILE" usage:"server certificate"`
+	KeyFile        string `key:"key_file" env:"TLS_KEY_FILE" usage:"server private key"`
+	ClientCAFile   string `key:"client_ca_file" env:"TLS_CLIENT_CA_FILE" usage:"CAs trusted for client certificates"`
+	ClientAuth     string `key:"client_auth" env:"TLS_CLIENT_AUTH" usage:"none, optional or require (default require with a client CA)"`
+	GRPCClientAuth string `key:"grpc_client_auth" env:"GRPC_TLS_CLIENT_AUTH" usage:"client_auth for the gRPC listener"`
+	HTTPClientAuth string `key:"http_client_auth" env:"HTTP_TLS_CLIENT_AUTH" usage:"client_auth for the REST listener"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		GRPCPort:        "50051",
+		HTTPPort:        "8080",
+		ShutdownTimeout: 30 * time.Second,
+		UploadMemory:    32 << 20,
+		HealthTimeout:   2 * time.Second,
+		Kafka:           config.DefaultKafka(),
+		Auth: AuthConfig{
+			JWTLeeway:      time.Minute,
+			JWTTenantClaim: "tenant",
+			JWTRolesClaim:  "roles",
+		},
+		Callback:  CallbackConfig{MaxSkew: 5 * time.Minute},
+		Admission: AdmissionConfig{LagInterval: 15 * time.Second, RetryAfter: 30 * time.Second},
+		Sweeper:   SweeperConfig{SLA: 15 * time.Minute, Interval: time.Minute, MaxRepublish: 2},
+		Tracing:   tracing.DefaultConfig(),
+		Logging:   logging.DefaultConfig(),
+	}
+}
+
+// Validate implements config.Validator.
+func (c *Config) Validate() error {
+	for name, port := range map[string]string{"grpc_port": c.GRPCPort, "http_port": c.HTTPPort} {
+		if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+			return fmt.Errorf("%s: %q is not a port", name, port)
+		}
+	}
+	if c.ShutdownTimeout <= 0 {
+		return fmt.Errorf("shutdown_timeout: %s, want a positive duration", c.ShutdownTimeout)
+	}
+	if c.UploadMemory <= 0 {
+		return fmt.Errorf("upload_memory: %d, want a positive size", c.UploadMemory)
+	}
+	if c.HealthTimeout <= 0 {
+		return fmt.Errorf("health_timeout: %s, want a positive duration", c.HealthTimeout)
+	}
+	return nil
+}
+
+// Validate implements config.Validator.
+func (c *ReviewConfig) Validate() error {
+	if c.MinConfidence < 0 || c.MinConfidence > 1 {
+		return fmt.Errorf("min_confidence: %g is outside [0, 1]", c.MinConfidence)
+	}
+	for name, t := range c.MinConfidenceByDataPoint {
+		if t < 0 || t > 1 {
+			return fmt.Errorf("min_confidence_by_data_point: %s=%g is outside [0, 1]", name, t)
+		}
+	}
+	return nil
+}
+
+// Validate implements config.Validator.
+func (c *AuthConfig) Validate() error {
+	if c.JWTLeeway < 0 {
+		return fmt.Errorf("jwt_leeway: %s is negative", c.JWTLeeway)
+	}
+	return nil
+}
+
+// Validate implements config.Validator.
+func (c *CallbackConfig) Validate() error {
+	if c.MaxSkew <= 0 {
+		return fmt.Errorf("max_skew: %s, want a positive duration", c.MaxSkew)
+	}
+	return nil
+}
+
+// Validate implements config.Validator.
+func (c *RateLimitConfig) Validate() error {
+	if c.RPS < 0 || c.TenantRPS < 0 || c.Burst < 0 || c.TenantBurst < 0 {
+		return errors.New("rates and bursts must not be negative")
+	}
+	return nil
+}
+
+// Validate implements config.Validator.
+func (c *AdmissionConfig) Validate() error {
+	switch {
+	case c.MaxPending < 0 || c.MaxLag < 0:
+		return errors.New("max_pending and max_lag must not be negative")
+	case c.MaxLag > 0 && c.LagInterval <= 0:
+		return fmt.Errorf("lag_interval: %s, want a positive duration", c.LagInterval)
+	case c.RetryAfter < 0:
+		return fmt.Errorf("retry_after: %s is negative", c.RetryAfter)
+	}
+	return nil
+}
+
+// Validate implements config.Validator.
+func (c *SweeperConfig) Validate() error {
+	switch {
+	case c.SLA < 0:
+		return fmt.Errorf("sla: %s is negative", c.SLA)
+	case c.SLA > 0 && c.Interval <= 0:
+		return fmt.Errorf("interval: %s, want a positive duration", c.Interval)
+	case c.MaxRepublish < 0:
+		return fmt.Errorf("max_republish: %d is negative", c.MaxRepublish)
+	}
+	return nil
+}
+
+// Validate implements config.Validator.
+func (c *TLSConfig) Validate() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("cert_file and key_file must be set together")
+	}
+	for _, mode := range []string{c.ClientAuth, c.GRPCClientAuth, c.HTTPClientAuth} {
+		switch mode {
+		case "", tlsutil.ClientAuthNone, tlsutil.ClientAuthOptional, tlsutil.ClientAuthRequire:
+		default:
+			return fmt.Errorf("client auth %q, want none, optional or require", mode)
+		}
+	}
+	return nil
+}
+
+// listener returns the TLS settings for one listener, applying its
+// client_auth override.
+func (c TLSConfig) listener(clientAuth string) tlsutil.Config {
+	if clientAuth == "" {
+		clientAuth = c.ClientAuth
+	}
+	return tlsutil.Config{
+		CertFile:     c.CertFile,
+		KeyFile:      c.KeyFile,
+		ClientCAFile: c.ClientCAFile,
+		ClientAuth:   clientAuth,
+	}
+}
@@ -0,0 +1,193 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Headers the consumer adds to a dead-lettered message; they must match
+// the consumer's.
+const (
+	deadLetterHeaderPrefix    = "dead_letter."
+	headerDeadLetterReason    = "dead_letter.reason"
+	headerDeadLetterError     = "dead_letter.error"
+	headerDeadLetterTopic     = "dead_letter.topic"
+	headerDeadLetterPartition = "dead_letter.partition"
+	headerDeadLetterOffset    = "dead_letter.offset"
+	headerDeadLetterAt        = "dead_letter.at"
+	headerDeadLetterWorkerID  = "dead_letter.worker_id"
+)
+
+// DeadLetter is an upload event the consumer could not process, read back
+// from the dead-letter topic. DocumentID and TenantID are empty when the
+// event itself could not be decoded.
+type DeadLetter struct {
+	Partition int32
+	Offset    int64
+
+	Reason          string
+	Error           string
+	SourceTopic     string
+	SourcePartition int32
+	SourceOffset    int64
+	FailedAt        time.Time
+	WorkerID        string
+
+	DocumentID string
+	TenantID   string
+
+	key     []byte
+	value   []byte
+	headers []sarama.RecordHeader
+}
+
+// DeadLetters reads the consumer's dead-letter topic.
+type DeadLetters struct {
+	client   sarama.Client
+	consumer sarama.Consumer
+	topic    string
+}
+
+// NewDeadLetters connects to brokers to read topic.
+func NewDeadLetters(brokers []string, topic string) (*DeadLetters, error) {
+	cfg := sarama.NewConfig()
+	cfg.Consumer.Return.Errors = true
+
+	client, err := sarama.NewClient(brokers, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("kafka: new client: %w", err)
+	}
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("kafka: new consumer: %w", err)
+	}
+	return &DeadLetters{client: client, consumer: consumer, topic: topic}, nil
+}
+
+// Topic returns the dead-letter topic's name.
+func (d *DeadLetters) Topic() string { return d.topic }
+
+// Read returns every dead letter the topic retains, by partition, oldest
+// first.
+func (d *DeadLetters) Read(ctx context.Context) ([]*DeadLetter, error) {
+	if err := d.client.RefreshMetadata(d.topic); err != nil {
+		return nil, fmt.Errorf("kafka: refresh metadata: %w", err)
+	}
+	ids, err := d.client.Partitions(d.topic)
+	if err != nil {
+		return nil, fmt.Errorf("kafka: list partitions: %w", err)
+	}
+	var out []*DeadLetter
+	for _, p := range ids {
+		oldest, err := d.client.GetOffset(d.topic, p, sarama.OffsetOldest)
+		if err != nil {
+			return nil, fmt.Errorf("kafka: oldest offset of partition %d: %w", p, err)
+		}
+		newest, err := d.client.GetOffset(d.topic, p, sarama.OffsetNewest)
+		if err != nil {
+			return nil, fmt.Errorf("kafka: newest offset of partition %d: %w", p, err)
+		}
+		if oldest >= newest {
+			continue
+		}
+		letters, err := d.readPartition(ctx, p, oldest, newest)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, letters...)
+	}
+	return out, nil
+}
+
+// readPartition reads partition p from offset from up to, but excluding,
+// offset to.
+func (d *DeadLetters) readPartition(ctx context.Context, p int32, from, to int64) ([]*DeadLetter, error) {
+	pc, err := d.consumer.ConsumePartition(d.topic, p, from)
+	if err != nil {
+		return nil, fmt.Errorf("kafka: consume partition %d: %w", p, err)
+	}
+	defer pc.Close()
+
+	var out []*DeadLetter
+	for next := from; next < to; {
+		select {
+		case msg := <-pc.Messages():
+			out = append(out, parseDeadLetter(msg))
+			next = msg.Offset + 1
+		case err := <-pc.Errors():
+			return nil, fmt.Errorf("kafka: read partition %d: %w", p, err)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return out, nil
+}
+
+func parseDeadLetter(msg *sarama.ConsumerMessage) *DeadLetter {
+	dl := &DeadLetter{Partition: msg.Partition, Offset: msg.Offset, key: msg.Key, value: msg.Value}
+	for _, h := range msg.Headers {
+		if h == nil {
+			continue
+		}
+		v := string(h.Value)
+		switch string(h.Key) {
+		case headerDeadLetterReason:
+			dl.Reason = v
+		case headerDeadLetterError:
+			dl.Error = v
+		case headerDeadLetterTopic:
+			dl.SourceTopic = v
+		case headerDeadLetterPartition:
+			if n, err := strconv.ParseInt(v, 10, 32); err == nil {
+				dl.SourcePartition = int32(n)
+			}
+		case headerDeadLetterOffset:
+			dl.SourceOffset, _ = strconv.ParseInt(v, 10, 64)
+		case headerDeadLetterAt:
+			dl.FailedAt, _ = time.Parse(time.RFC3339Nano, v)
+		case headerDeadLetterWorkerID:
+			dl.WorkerID = v
+		default:
+			if !strings.HasPrefix(string(h.Key), deadLetterHeaderPrefix) {
+				dl.headers = append(dl.headers, *h)
+			}
+		}
+	}
+	var evt documentUploadEvent
+	if json.Unmarshal(msg.Value, &evt) == nil {
+		dl.DocumentID, dl.TenantID = evt.DocumentID, evt.TenantID
+	}
+	return dl
+}
+
+// Close releases the reader's connections.
+func (d *DeadLetters) Close() error {
+	err := d.consumer.Close()
+	if cerr := d.client.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Replay publishes dl to the uploads topic as it was first received, with
+// the dead-letter headers removed, and returns the partition and offset it
+// was written to. The trace context and request ID of ctx replace the
+// original ones, so the retry is traced and logged as part of the replay.
+func (p *Producer) Replay(ctx context.Context, dl *DeadLetter) (int32, int64, error) {
+	ctx, span := startPublishSpan(ctx, p.topic, dl.DocumentID, dl.TenantID)
+	defer span.End()
+
+	msg := &sarama.ProducerMessage{Topic: p.topic, Value: sarama.ByteEncoder(dl.value)}
+	if dl.key != nil {
+		msg.Key = sarama.ByteEncoder(dl.key)
+	}
+	msg.Headers = append(msg.Headers, dl.headers...)
+	return p.send(ctx, span, msg)
+}
@@ -0,0 +1,68 @@
+package kafka
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+func header(k, v string) *sarama.RecordHeader {
+	return &sarama.RecordHeader{Key: []byte(k), Value: []byte(v)}
+}
+
+func TestParseDeadLetter(t *testing.T) {
+	failedAt := time.Date(2024, 3, 1, 12, 30, 0, 500, time.UTC)
+	msg := &sarama.ConsumerMessage{
+		Partition: 2,
+		Offset:    41,
+		Key:       []byte("doc-1"),
+		Value:     []byte(`{"document_id":"doc-1","tenant_id":"acme","filename":"a.pdf"}`),
+		Headers: []*sarama.RecordHeader{
+			header("traceparent", "00-abc-def-01"),
+			header(headerDeadLetterReason, "nlp"),
+			header(headerDeadLetterError, "nlp service: 503"),
+			header(headerDeadLetterTopic, "document-uploads"),
+			header(headerDeadLetterPartition, "1"),
+			header(headerDeadLetterOffset, "977"),
+			header(headerDeadLetterAt, failedAt.Format(time.RFC3339Nano)),
+			header(headerDeadLetterWorkerID, "worker-3"),
+			header("dead_letter.retries", "4"),
+			nil,
+			header("x-request-id", "r-9"),
+		},
+	}
+	got := parseDeadLetter(msg)
+	want := &DeadLetter{
+		Partition:       2,
+		Offset:          41,
+		Reason:          "nlp",
+		Error:           "nlp service: 503",
+		SourceTopic:     "document-uploads",
+		SourcePartition: 1,
+		SourceOffset:    977,
+		FailedAt:        failedAt,
+		WorkerID:        "worker-3",
+		DocumentID:      "doc-1",
+		TenantID:        "acme",
+		key:             msg.Key,
+		value:           msg.Value,
+		// Only the original headers are replayed.
+		headers: []sarama.RecordHeader{*msg.Headers[0], *msg.Headers[10]},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDeadLetter =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestParseDeadLetterUndecodable(t *testing.T) {
+	got := parseDeadLetter(&sarama.ConsumerMessage{
+		Offset:  7,
+		Value:   []byte("not json"),
+		Headers: []*sarama.RecordHeader{header(headerDeadLetterReason, "invalid"), header(headerDeadLetterPartition, "x")},
+	})
+	if got.Reason != "invalid" || got.DocumentID != "" || got.TenantID != "" || got.SourcePartition != 0 || got.headers != nil {
+		t.Errorf("parseDeadLetter = %+v", got)
+	}
+}
@@ -0,0 +1,144 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/config"
+)
+
+// LagMonitor periodically measures how many document-upload events a
+// consumer group has yet to process.
+type LagMonitor struct {
+	client sarama.Client
+	admin  sarama.ClusterAdmin
+	group  string
+	topic  string
+	// initial is where the group starts on a partition it has never
+	// committed on: sarama.OffsetOldest or sarama.OffsetNewest.
+	initial int64
+
+	mu         sync.RWMutex
+	partitions map[int32]int64
+	err        error
+	measured   time.Time
+}
+
+// NewLagMonitor connects to brokers to watch group's progress on topic.
+// initialOffset is the group's starting offset, config.OffsetNewest or
+// config.OffsetOldest, which lag is measured from until the group commits.
+func NewLagMonitor(brokers []string, group, topic, initialOffset string) (*LagMonitor, error) {
+	client, err := sarama.NewClient(brokers, sarama.NewConfig())
+	if err != nil {
+		return nil, fmt.Errorf("kafka: new client: %w", err)
+	}
+	admin, err := sarama.NewClusterAdminFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("kafka: new cluster admin: %w", err)
+	}
+	initial := sarama.OffsetNewest
+	if initialOffset == config.OffsetOldest {
+		initial = sarama.OffsetOldest
+	}
+	return &LagMonitor{client: client, admin: admin, group: group, topic: topic, initial: initial}, nil
+}
+
+// Run measures lag every interval until ctx is cancelled.
+func (m *LagMonitor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		partitions, err := m.measure()
+		if err != nil {
+			slog.Warn("kafka: measure consumer lag", "error", err)
+		}
+		m.mu.Lock()
+		if err == nil {
+			m.partitions = partitions
+			m.measured = time.Now()
+		}
+		m.err = err
+		m.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Lag returns the total lag from the last measurement, or the error of the
+// last attempt.
+func (m *LagMonitor) Lag() (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.err != nil {
+		return 0, m.err
+	}
+	if m.measured.IsZero() {
+		return 0, fmt.Errorf("kafka: consumer lag not measured yet")
+	}
+	var total int64
+	for _, lag := range m.partitions {
+		total += lag
+	}
+	return total, nil
+}
+
+// PartitionLag returns the last measured lag of each partition.
+func (m *LagMonitor) PartitionLag() map[int32]int64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make(map[int32]int64, len(m.partitions))
+	for p, lag := range m.partitions {
+		out[p] = lag
+	}
+	return out
+}
+
+func (m *LagMonitor) measure() (map[int32]int64, error) {
+	if err := m.client.RefreshMetadata(m.topic); err != nil {
+		return nil, fmt.Errorf("refresh metadata: %w", err)
+	}
+	ids, err := m.client.Partitions(m.topic)
+	if err != nil {
+		return nil, fmt.Errorf("list partitions: %w", err)
+	}
+	committed, err := m.admin.ListConsumerGroupOffsets(m.group, map[string][]int32{m.topic: ids})
+	if err != nil {
+		return nil, fmt.Errorf("fetch group offsets: %w", err)
+	}
+
+	lag := make(map[int32]int64, len(ids))
+	for _, p := range ids {
+		newest, err := m.client.GetOffset(m.topic, p, sarama.OffsetNewest)
+		if err != nil {
+			return nil, fmt.Errorf("newest offset of partition %d: %w", p, err)
+		}
+		// A partition the group has never committed on is read from the
+		// consumer's initial offset: every retained event is behind when
+		// that is the oldest offset, none when it is the newest.
+		from := newest
+		if block := committed.GetBlock(m.topic, p); block != nil && block.Offset >= 0 {
+			from = block.Offset
+		} else if m.initial == sarama.OffsetOldest {
+			if from, err = m.client.GetOffset(m.topic, p, sarama.OffsetOldest); err != nil {
+				return nil, fmt.Errorf("oldest offset of partition %d: %w", p, err)
+			}
+		}
+		lag[p] = max(newest-from, 0)
+	}
+	return lag, nil
+}
+
+// Close releases the monitor's connections.
+func (m *LagMonitor) Close() error {
+	return m.admin.Close()
+}
@@ -2,69 +2,175 @@
 package kafka
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
-	"strings"
+	"log/slog"
 
 	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/logging"
 )
 
-const topicDocumentUploads = "document-uploads"
+var tracer = otel.Tracer("github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/kafka")
 
 // Producer wraps a Sarama SyncProducer.
 type Producer struct {
-	sp sarama.SyncProducer
+	client sarama.Client
+	sp     sarama.SyncProducer
+	topic  string
 }
 
-// documentUploadEvent is the JSON payload published to document-uploads.
+// documentUploadEvent is the JSON payload published to the uploads topic.
 type documentUploadEvent struct {
 	DocumentID    string   `json:"document_id"`
+	TenantID      string   `json:"tenant_id"`
 	Filename      string   `json:"filename"`
 	PDFDataBase64 string   `json:"pdf_data_base64"`
 	DataPoints    []string `json:"data_points"`
 }
 
 // NewProducer creates a synchronous Kafka producer connected to brokers
-// (comma-separated list, e.g. "kafka:9092").
-func NewProducer(brokers string) (*Producer, error) {
+// that publishes document-upload events to topic.
+func NewProducer(brokers []string, topic string) (*Producer, error) {
 	cfg := sarama.NewConfig()
 	cfg.Producer.Return.Successes = true
 	cfg.Producer.Return.Errors = true
 
-	sp, err := sarama.NewSyncProducer(strings.Split(brokers, ","), cfg)
+	client, err := sarama.NewClient(brokers, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("kafka: new client: %w", err)
+	}
+	sp, err := sarama.NewSyncProducerFromClient(client)
 	if err != nil {
+		client.Close()
 		return nil, fmt.Errorf("kafka: new sync producer: %w", err)
 	}
-	return &Producer{sp: sp}, nil
+	return &Producer{client: client, sp: sp, topic: topic}, nil
 }
 
-// PublishDocumentUpload sends a document-upload event to the document-uploads topic.
-func (p *Producer) PublishDocumentUpload(docID, filename, pdfBase64 string, dataPoints []string) error {
+// PublishDocumentUpload sends a document-upload event to the uploads topic
+// and returns the partition and offset it was written to.
+// The trace context and request ID of ctx travel in the record headers so
+// the consumer can continue the trace and log under the same request ID.
+func (p *Producer) PublishDocumentUpload(ctx context.Context, docID, tenantID, filename, pdfBase64 string, dataPoints []string) (int32, int64, error) {
+	ctx, span := startPublishSpan(ctx, p.topic, docID, tenantID)
+	defer span.End()
+
 	evt := documentUploadEvent{
 		DocumentID:    docID,
+		TenantID:      tenantID,
 		Filename:      filename,
 		PDFDataBase64: pdfBase64,
 		DataPoints:    dataPoints,
 	}
 	payload, err := json.Marshal(evt)
 	if err != nil {
-		return fmt.Errorf("kafka: marshal event: %w", err)
+		return 0, 0, fmt.Errorf("kafka: marshal event: %w", err)
 	}
 
 	msg := &sarama.ProducerMessage{
-		Topic: topicDocumentUploads,
+		Topic: p.topic,
 		Value: sarama.ByteEncoder(payload),
 	}
-	_, _, err = p.sp.SendMessage(msg)
+	partition, offset, err := p.send(ctx, span, msg)
 	if err != nil {
-		return fmt.Errorf("kafka: send message: %w", err)
+		return 0, 0, err
+	}
+	slog.InfoContext(ctx, "kafka: published upload event",
+		"document_id", docID, "tenant", tenantID, "partition", partition, "offset", offset)
+	return partition, offset, nil
+}
+
+// startPublishSpan starts the producer span of an upload event.
+func startPublishSpan(ctx context.Context, topic, docID, tenantID string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.destination.name", topic),
+			attribute.String("document_id", docID),
+			attribute.String("tenant", tenantID),
+		))
+}
+
+// send puts the trace context and request ID of ctx in msg's headers and
+// sends it, recording the outcome on span.
+func (p *Producer) send(ctx context.Context, span trace.Span, msg *sarama.ProducerMessage) (int32, int64, error) {
+	otel.GetTextMapPropagator().Inject(ctx, headerCarrier{&msg.Headers})
+	if id := logging.RequestID(ctx); id != "" {
+		headerCarrier{&msg.Headers}.Set(logging.RequestIDKey, id)
+	}
+	partition, offset, err := p.sp.SendMessage(msg)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "send failed")
+		return 0, 0, fmt.Errorf("kafka: send message: %w", err)
+	}
+	span.SetAttributes(
+		attribute.Int("messaging.kafka.destination.partition", int(partition)),
+		attribute.Int64("messaging.kafka.message.offset", offset),
+	)
+	return partition, offset, nil
+}
+
+// headerCarrier lets a propagator read and write Kafka record headers.
+type headerCarrier struct {
+	headers *[]sarama.RecordHeader
+}
+
+func (c headerCarrier) Get(key string) string {
+	for _, h := range *c.headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
 	}
-	log.Printf("kafka: published upload event doc_id=%s", docID)
-	return nil
+	return ""
 }
 
-// Close shuts down the producer gracefully.
+func (c headerCarrier) Set(key, value string) {
+	for i, h := range *c.headers {
+		if string(h.Key) == key {
+			(*c.headers)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c.headers = append(*c.headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+func (c headerCarrier) Keys() []string {
+	keys := make([]string, len(*c.headers))
+	for i, h := range *c.headers {
+		keys[i] = string(h.Key)
+	}
+	return keys
+}
+
+// Ping refreshes the topic's metadata, which needs a broker to answer.
+func (p *Producer) Ping(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() { done <- p.client.RefreshMetadata(p.topic) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("kafka: refresh metadata: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close flushes pending messages and shuts down the producer and its
+// connections.
 func (p *Producer) Close() error {
-	return p.sp.Close()
+	err := p.sp.Close()
+	if cerr := p.client.Close(); err == nil {
+		err = cerr
+	}
+	return err
 }
@@ -1,60 +1,405 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
+	"github.com/ryan-dayrit/nlp-pdf-extractor/config"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/logging"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/tracing"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/auth"
 	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/kafka"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/metrics"
 	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/pb"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/ratelimit"
 	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/server"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/tlsutil"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/usage"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/validation"
 )
 
-func getEnv(key, fallback string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
+// loadAuthenticator builds the authenticator chain from the auth settings.
+// It returns nil when no credential source is configured.
+func loadAuthenticator(cfg AuthConfig) (auth.Authenticator, error) {
+	var chain auth.Chain
+	if cfg.APIKeysFile != "" {
+		keys, err := auth.LoadAPIKeys(cfg.APIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, keys)
+		slog.Info("auth: API keys loaded", "path", cfg.APIKeysFile)
+	}
+	if cfg.JWKSFile != "" {
+		jwt, err := auth.NewJWT(auth.JWTConfig{
+			JWKSFile:    cfg.JWKSFile,
+			Issuer:      cfg.JWTIssuer,
+			Audience:    cfg.JWTAudience,
+			Leeway:      cfg.JWTLeeway,
+			TenantClaim: cfg.JWTTenantClaim,
+			RolesClaim:  cfg.JWTRolesClaim,
+		})
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, jwt)
+		slog.Info("auth: JWT keys loaded", "path", cfg.JWKSFile)
+	}
+	if len(chain) == 0 {
+		return nil, nil
+	}
+	return chain, nil
+}
+
+// loadRateLimits builds the per-caller and per-tenant limiters. A zero rate
+// disables a limiter.
+func loadRateLimits(cfg RateLimitConfig) ([]*ratelimit.Limiter, error) {
+	var limiters []*ratelimit.Limiter
+	for _, l := range []struct {
+		scope ratelimit.Scope
+		rps   float64
+		burst int
+	}{
+		{ratelimit.ScopeCaller, cfg.RPS, cfg.Burst},
+		{ratelimit.ScopeTenant, cfg.TenantRPS, cfg.TenantBurst},
+	} {
+		if l.rps == 0 {
+			continue
+		}
+		if l.burst == 0 {
+			l.burst = int(math.Ceil(l.rps))
+		}
+		limiter, err := ratelimit.New(l.scope, l.rps, l.burst)
+		if err != nil {
+			return nil, fmt.Errorf("per %s: %w", l.scope, err)
+		}
+		slog.Info("rate limit enabled", "scope", l.scope, "rps", l.rps, "burst", l.burst)
+		limiters = append(limiters, limiter)
+	}
+	return limiters, nil
+}
+
+// loadAdmission builds the admission policy. The consumer lag monitor is
+// only started when max_lag is set, and runs until ctx is done.
+func loadAdmission(ctx context.Context, cfg AdmissionConfig, k config.Kafka) server.AdmissionPolicy {
+	p := server.AdmissionPolicy{
+		MaxPending: cfg.MaxPending,
+		MaxLag:     cfg.MaxLag,
+		RetryAfter: cfg.RetryAfter,
+	}
+	if p.MaxLag > 0 {
+		monitor, err := kafka.NewLagMonitor(k.Brokers, k.GroupID, k.Topic, k.InitialOffset)
+		if err != nil {
+			// Admission fails open without lag data; pending limits still apply.
+			slog.Warn("consumer lag unavailable; admission max_lag is not enforced", "error", err)
+			return p
+		}
+		go func() {
+			monitor.Run(ctx, cfg.LagInterval)
+			monitor.Close()
+		}()
+		p.Lag = monitor
+	}
+	return p
+}
+
+// loadTLS returns the TLS configuration for a listener, or nil when no
+// certificate is configured.
+func loadTLS(cfg tlsutil.Config, nextProtos ...string) (*tls.Config, error) {
+	if cfg.CertFile == "" {
+		return nil, nil
+	}
+	r, err := tlsutil.NewReloader(cfg)
+	if err != nil {
+		return nil, err
 	}
-	return fallback
+	return r.ServerConfig(nextProtos...), nil
 }
 
 func main() {
-	kafkaBrokers := getEnv("KAFKA_BROKERS", "kafka:9092")
-	grpcPort := getEnv("GRPC_PORT", "50051")
-	httpPort := getEnv("HTTP_PORT", "8080")
+	cfg := defaultConfig()
+	if err := config.Load(&cfg); err != nil {
+		fatal("config", err)
+	}
+	logLevel, err := logging.Setup("grpc-service", cfg.Logging)
+	if err != nil {
+		fatal("logging", err)
+	}
+
+	// ctx is cancelled on SIGINT/SIGTERM and stops background work.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	flushTraces, err := tracing.Setup(ctx, "grpc-service", cfg.Tracing)
+	if err != nil {
+		fatal("tracing", err)
+	}
 
 	// Kafka producer — optional; service stays up even if Kafka is unavailable.
+	// It is closed by shutdown once no request can publish any more.
 	var producer *kafka.Producer
-	if p, err := kafka.NewProducer(kafkaBrokers); err != nil {
-		log.Printf("warning: kafka unavailable (%v) — uploads will not be published", err)
+	if p, err := kafka.NewProducer(cfg.Kafka.Brokers, cfg.Kafka.Topic); err != nil {
+		slog.Warn("kafka unavailable; uploads will not be published", "error", err)
 	} else {
 		producer = p
-		defer producer.Close()
 	}
 
-	srv := server.NewServer(producer)
+	// The dead-letter reader is optional too; without it the admin API
+	// cannot list or replay dead letters.
+	var deadLetters *kafka.DeadLetters
+	if topic := cfg.Kafka.DeadLetterTopic; topic != "" {
+		if d, err := kafka.NewDeadLetters(cfg.Kafka.Brokers, topic); err != nil {
+			slog.Warn("kafka unavailable; dead letters cannot be listed or replayed", "error", err)
+		} else {
+			deadLetters = d
+		}
+	}
 
-	// gRPC server on grpcPort
-	go func() {
-		lis, err := net.Listen("tcp", fmt.Sprintf(":%s", grpcPort))
-		if err != nil {
-			log.Fatalf("grpc: listen: %v", err)
+	var rules *validation.RuleSet
+	if path := cfg.Validation.RulesFile; path != "" {
+		if rules, err = validation.Load(path); err != nil {
+			fatal("validation rules", err)
 		}
-		gs := grpc.NewServer()
-		pb.RegisterExtractorServiceServer(gs, srv)
-		log.Printf("gRPC server listening on :%s", grpcPort)
+		slog.Info("validation rules loaded", "path", path)
+	}
+
+	var quotas *usage.Quotas
+	if path := cfg.Usage.QuotasFile; path != "" {
+		if quotas, err = usage.LoadQuotas(path); err != nil {
+			fatal("usage quotas", err)
+		}
+		slog.Info("usage quotas loaded", "path", path)
+	}
+
+	authn, err := loadAuthenticator(cfg.Auth)
+	if err != nil {
+		fatal("auth", err)
+	}
+	var policy *auth.Policy
+	if path := cfg.Auth.PolicyFile; path != "" {
+		if policy, err = auth.LoadPolicy(path); err != nil {
+			fatal("access policy", err)
+		}
+		slog.Info("access policy loaded", "path", path)
+	}
+
+	// Request IDs and metrics come first so that requests refused by later
+	// interceptors are logged with their ID and counted too.
+	grpcOpts := []grpc.ServerOption{
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(requestIDUnaryInterceptor, metrics.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(requestIDStreamInterceptor, metrics.StreamServerInterceptor()),
+	}
+	if authn != nil {
+		grpcOpts = append(grpcOpts,
+			grpc.ChainUnaryInterceptor(auth.UnaryServerInterceptor(authn)),
+			grpc.ChainStreamInterceptor(auth.StreamServerInterceptor(authn)),
+		)
+	} else {
+		slog.Warn("no API keys or JWKS configured; APIs are unauthenticated")
+	}
+
+	limits, err := loadRateLimits(cfg.RateLimit)
+	if err != nil {
+		fatal("rate limits", err)
+	}
+	if len(limits) > 0 {
+		grpcOpts = append(grpcOpts,
+			grpc.ChainUnaryInterceptor(ratelimit.UnaryServerInterceptor(limits)),
+			grpc.ChainStreamInterceptor(ratelimit.StreamServerInterceptor(limits)),
+		)
+	}
+	grpcTLS, err := loadTLS(cfg.TLS.listener(cfg.TLS.GRPCClientAuth), "h2")
+	if err != nil {
+		fatal("grpc tls", err)
+	}
+	if grpcTLS != nil {
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(grpcTLS)))
+	}
+	httpTLS, err := loadTLS(cfg.TLS.listener(cfg.TLS.HTTPClientAuth), "h2", "http/1.1")
+	if err != nil {
+		fatal("http tls", err)
+	}
+
+	var callbacks *auth.HMACVerifier
+	if secret := cfg.Callback.HMACSecret; secret != "" {
+		callbacks = auth.NewHMACVerifier([]byte(secret), "consumer", cfg.Callback.MaxSkew)
+	} else {
+		slog.Warn("CALLBACK_HMAC_SECRET not set; result callbacks from the consumer will be rejected")
+	}
+
+	srv := server.NewServer(producer, server.Options{
+		Review: server.ReviewPolicy{
+			MinConfidence:            cfg.Review.MinConfidence,
+			MinConfidenceByDataPoint: cfg.Review.MinConfidenceByDataPoint,
+		},
+		Rules:         rules,
+		Auth:          authn,
+		Callbacks:     callbacks,
+		Policy:        policy,
+		RateLimits:    limits,
+		Admission:     loadAdmission(ctx, cfg.Admission, cfg.Kafka),
+		Quotas:        quotas,
+		UploadMemory:  cfg.UploadMemory,
+		HealthTimeout: cfg.HealthTimeout,
+		Sweeper: server.SweeperPolicy{
+			SLA:          cfg.Sweeper.SLA,
+			MaxRepublish: cfg.Sweeper.MaxRepublish,
+			Interval:     cfg.Sweeper.Interval,
+		},
+		LogLevel:    logLevel,
+		DeadLetters: deadLetters,
+	})
+
+	metrics.RegisterDocuments(srv.DocumentCounts)
+
+	// The sweeper stops with ctx; shutdown waits for it so that it never
+	// publishes on a closed producer.
+	sweeperDone := make(chan struct{})
+	go func() {
+		defer close(sweeperDone)
+		srv.RunSweeper(ctx)
+	}()
+	if cfg.Sweeper.SLA > 0 {
+		slog.Info("stuck-document sweeper enabled", "sla", cfg.Sweeper.SLA,
+			"interval", cfg.Sweeper.Interval, "max_republish", cfg.Sweeper.MaxRepublish)
+	}
+
+	grpcPort, httpPort := cfg.GRPCPort, cfg.HTTPPort
+	gs := grpc.NewServer(grpcOpts...)
+	pb.RegisterExtractorServiceServer(gs, srv)
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", grpcPort))
+	if err != nil {
+		fatal("grpc: listen", err)
+	}
+	hs := &http.Server{
+		Addr:      fmt.Sprintf(":%s", httpPort),
+		Handler:   traceHTTP(logging.Middleware(metrics.Middleware(srv.NewHTTPMux()))),
+		TLSConfig: httpTLS,
+	}
+
+	serveErr := make(chan error, 2)
+	go func() {
+		slog.Info("gRPC server listening", "port", grpcPort)
 		if err := gs.Serve(lis); err != nil {
-			log.Fatalf("grpc: serve: %v", err)
+			serveErr <- fmt.Errorf("grpc: serve: %w", err)
+		}
+	}()
+	go func() {
+		var err error
+		if httpTLS != nil {
+			slog.Info("HTTPS server listening", "port", httpPort)
+			err = hs.ListenAndServeTLS("", "")
+		} else {
+			slog.Info("HTTP server listening", "port", httpPort)
+			err = hs.ListenAndServe()
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- fmt.Errorf("http: serve: %w", err)
+		}
+	}()
+
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		slog.Info("shutdown signal received")
+	case err := <-serveErr:
+		slog.Error("server failed", "error", err)
+		exitCode = 1
+	}
+	stop()
+	<-sweeperDone
+	shutdown(gs, hs, producer, cfg.ShutdownTimeout)
+	if deadLetters != nil {
+		if err := deadLetters.Close(); err != nil {
+			slog.Error("kafka: close dead-letter reader", "error", err)
+		}
+	}
+	flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	if err := flushTraces(flushCtx); err != nil {
+		slog.Error("tracing: flush", "error", err)
+	}
+	cancel()
+	os.Exit(exitCode)
+}
+
+// fatal logs err and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// traceHTTP starts a server span for each REST request, continuing the
+// caller's trace. Probes and scrapes are not traced.
+func traceHTTP(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "http",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method + " " + metrics.Route(r.URL.Path)
+		}),
+		otelhttp.WithFilter(func(r *http.Request) bool {
+			switch r.URL.Path {
+			case "/livez", "/readyz", "/metrics":
+				return false
+			}
+			return true
+		}),
+	)
+}
+
+// shutdown stops accepting connections on both listeners, lets in-flight
+// requests finish within timeout (then cuts them off), and finally closes
+// the Kafka producer so every accepted upload has been published.
+func shutdown(gs *grpc.Server, hs *http.Server, producer *kafka.Producer, timeout time.Duration) {
+	slog.Info("shutting down: draining requests", "timeout", timeout)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		stopped := make(chan struct{})
+		go func() {
+			gs.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			slog.Warn("grpc: drain timed out; closing remaining connections")
+			gs.Stop()
 		}
 	}()
+	go func() {
+		defer wg.Done()
+		if err := hs.Shutdown(ctx); err != nil {
+			slog.Warn("http: drain failed; closing remaining connections", "error", err)
+			hs.Close()
+		}
+	}()
+	wg.Wait()
 
-	// HTTP/REST server on httpPort (blocks main goroutine)
-	mux := srv.NewHTTPMux()
-	log.Printf("HTTP server listening on :%s", httpPort)
-	if err := http.ListenAndServe(fmt.Sprintf(":%s", httpPort), mux); err != nil {
-		log.Fatalf("http: serve: %v", err)
+	if producer != nil {
+		if err := producer.Close(); err != nil {
+			slog.Error("kafka: close producer", "error", err)
+		}
 	}
+	slog.Info("shutdown complete")
 }
@@ -0,0 +1,247 @@
+// Package metrics defines the grpc-service's Prometheus metrics, served at
+// GET /metrics, together with the gRPC interceptors and HTTP middleware that
+// record request counts and latencies.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "pdf_extractor"
+
+// Upload outcomes recorded by Uploads.
+const (
+	UploadAccepted          = "accepted"
+	UploadUnpublished       = "unpublished"
+	UploadRejectedAdmission = "rejected_admission"
+	UploadRejectedQuota     = "rejected_quota"
+)
+
+var (
+	// Uploads counts upload attempts by outcome. Unpublished documents were
+	// stored but could not be sent to Kafka.
+	Uploads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "uploads_total",
+		Help:      "Document uploads by outcome.",
+	}, []string{"status"})
+
+	// ExtractionDuration measures from when a document is queued for
+	// extraction to when its results are stored, by resulting status.
+	ExtractionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "extraction_duration_seconds",
+		Help:      "Time from upload or reprocess to stored results.",
+		Buckets:   prometheus.ExponentialBuckets(0.25, 2, 12),
+	}, []string{"status"})
+
+	// KafkaPublished and KafkaPublishErrors count document-upload events.
+	KafkaPublished = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_published_total",
+		Help:      "Document-upload events published to Kafka.",
+	})
+	KafkaPublishErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_publish_errors_total",
+		Help:      "Document-upload events that failed to publish.",
+	})
+
+	// SweeperRepublished and SweeperTimedOut count stuck documents the
+	// sweeper sent back to Kafka or gave up on; SweeperStuck is how many
+	// were overdue at its last pass.
+	SweeperRepublished = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sweeper_republished_total",
+		Help:      "Stuck documents republished to Kafka by the sweeper.",
+	})
+	SweeperTimedOut = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sweeper_timed_out_total",
+		Help:      "Stuck documents marked failed by the sweeper.",
+	})
+	SweeperStuck = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "sweeper_stuck_documents",
+		Help:      "Pending or processing documents past the SLA at the last sweep.",
+	})
+
+	dataPointResults = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "data_point_results_total",
+		Help:      "Extracted data point results by data point and status (found, not_found, ambiguous, error, skipped).",
+	}, []string{"data_point", "status"})
+
+	grpcRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "gRPC requests by method and status code.",
+	}, []string{"method", "code"})
+	grpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "gRPC request latency by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "REST requests by method, route and status code.",
+	}, []string{"method", "route", "code"})
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "REST request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// Handler serves the registered metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// maxDataPointLabels bounds the data_point label: data point names are
+// chosen by uploaders, so names beyond the first this many are reported as
+// "other".
+const maxDataPointLabels = 200
+
+var dataPointNames = struct {
+	sync.Mutex
+	seen map[string]bool
+}{seen: make(map[string]bool)}
+
+// DataPointResult counts one extracted data point.
+func DataPointResult(dataPoint, status string) {
+	dataPointNames.Lock()
+	if !dataPointNames.seen[dataPoint] {
+		if len(dataPointNames.seen) < maxDataPointLabels {
+			dataPointNames.seen[dataPoint] = true
+		} else {
+			dataPoint = "other"
+		}
+	}
+	dataPointNames.Unlock()
+	dataPointResults.WithLabelValues(dataPoint, status).Inc()
+}
+
+// RegisterDocuments exports pdf_extractor_documents, the number of stored
+// documents by status, computed by count at each scrape.
+func RegisterDocuments(count func() map[string]int) {
+	prometheus.MustRegister(&documentsCollector{count: count})
+}
+
+var documentsDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "documents"),
+	"Stored documents by status.",
+	[]string{"status"}, nil,
+)
+
+type documentsCollector struct {
+	count func() map[string]int
+}
+
+func (c *documentsCollector) Describe(ch chan<- *prometheus.Desc) { ch <- documentsDesc }
+
+func (c *documentsCollector) Collect(ch chan<- prometheus.Metric) {
+	for status, n := range c.count() {
+		ch <- prometheus.MustNewConstMetric(documentsDesc, prometheus.GaugeValue, float64(n), status)
+	}
+}
+
+// UnaryServerInterceptor records gRPC request metrics.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observeGRPC(info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor records gRPC request metrics; a stream's latency
+// is its whole lifetime.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		observeGRPC(info.FullMethod, start, err)
+		return err
+	}
+}
+
+func observeGRPC(fullMethod string, start time.Time, err error) {
+	method := fullMethod[strings.LastIndex(fullMethod, "/")+1:]
+	grpcRequests.WithLabelValues(method, status.Code(err).String()).Inc()
+	grpcDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// Middleware records REST request metrics. Paths are reduced to route
+// templates so document IDs do not become labels.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, code: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		route := Route(r.URL.Path)
+		httpRequests.WithLabelValues(r.Method, route, strconv.Itoa(rec.code)).Inc()
+		httpDuration.WithLabelValues(r.Method, route).Observe(time.Since(start).Seconds())
+	})
+}
+
+// routeRoots are the first path segments the REST API serves.
+var routeRoots = map[string]bool{
+	"documents": true, "review": true, "usage": true, "admin": true,
+	"livez": true, "readyz": true, "metrics": true,
+}
+
+// Route maps a request path onto its route template, e.g.
+// "/documents/6f1c…/datapoints" to "/documents/{id}/datapoints". Paths
+// outside the API become "other".
+func Route(path string) string {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	if !routeRoots[segs[0]] {
+		return "other"
+	}
+	if len(segs) > 1 && (segs[0] == "documents" || segs[0] == "review") && segs[1] != "queue" {
+		segs[1] = "{id}"
+	}
+	if len(segs) > 3 {
+		return "other"
+	}
+	return "/" + strings.Join(segs, "/")
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	code    int
+	written bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.written {
+		r.code, r.written = code, true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.written = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter { return r.ResponseWriter }
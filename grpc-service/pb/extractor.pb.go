@@ -22,33 +22,458 @@ type GetDataPointsRequest struct {
 
 // GetDataPointsResponse is the response from GetDataPoints.
 type GetDataPointsResponse struct {
-	DocumentId string            `json:"document_id"`
-	Status     string            `json:"status"`
-	Results    map[string]string `json:"results"`
+	DocumentId    string                      `json:"document_id"`
+	Status        string                      `json:"status"`
+	Results       map[string]string           `json:"results"`
+	Details       map[string]*DataPointResult `json:"details"`
+	Revision      int32                       `json:"revision"`
+	ReviewReasons []string                    `json:"review_reasons"`
+	Violations    []*Violation                `json:"violations"`
+	Processing    *ProcessingInfo             `json:"processing,omitempty"`
+	FailureReason string                      `json:"failure_reason,omitempty"`
 }
 
-// ListDocumentsRequest is the request for ListDocuments.
-type ListDocumentsRequest struct{}
+// ProcessingInfo describes the consumer working on a document. Times are
+// RFC 3339 with fractional seconds.
+type ProcessingInfo struct {
+	WorkerId  string `json:"worker_id"`
+	StartedAt string `json:"started_at"`
+	UpdatedAt string `json:"updated_at"`
+	Stage     string `json:"stage"`
+	Message   string `json:"message,omitempty"`
+}
+
+// Violation describes a validation rule the current results fail.
+type Violation struct {
+	Rule       string   `json:"rule"`
+	DataPoints []string `json:"data_points"`
+	Message    string   `json:"message"`
+}
+
+// BoundingBox is the rectangle, in PDF points, enclosing an extracted value.
+type BoundingBox struct {
+	X0 float64 `json:"x0"`
+	Y0 float64 `json:"y0"`
+	X1 float64 `json:"x1"`
+	Y1 float64 `json:"y1"`
+}
+
+// Candidate is one possible value for a data point together with its
+// confidence and the location of the evidence in the source PDF.
+type Candidate struct {
+	Value       string       `json:"value"`
+	Confidence  float64      `json:"confidence"`
+	Page        int32        `json:"page"`
+	Bbox        *BoundingBox `json:"bbox,omitempty"`
+	StartOffset int32        `json:"start_offset"`
+	EndOffset   int32        `json:"end_offset"`
+	Snippet     string       `json:"snippet"`
+}
+
+// DataPointResult is the chosen value for a data point together with its
+// confidence, its location in the source PDF and every ranked candidate
+// found for it (best first; Candidates[0] mirrors the chosen value).
+// Status is one of found, not_found, ambiguous, error or skipped and Reason
+// explains any status other than found.
+type DataPointResult struct {
+	Value       string       `json:"value"`
+	Confidence  float64      `json:"confidence"`
+	Page        int32        `json:"page"`
+	Bbox        *BoundingBox `json:"bbox,omitempty"`
+	StartOffset int32        `json:"start_offset"`
+	EndOffset   int32        `json:"end_offset"`
+	Snippet     string       `json:"snippet"`
+	Candidates  []*Candidate `json:"candidates"`
+	Status      string       `json:"status"`
+	Reason      string       `json:"reason,omitempty"`
+}
+
+// ListDocumentsRequest is the request for ListDocuments. A zero PageSize
+// returns every matching document in one page.
+type ListDocumentsRequest struct {
+	PageSize  int32  `json:"page_size"`
+	PageToken string `json:"page_token"`
+	Status    string `json:"status"`
+}
 
 // ListDocumentsResponse is the response from ListDocuments.
 type ListDocumentsResponse struct {
-	Documents []*DocumentSummary `json:"documents"`
+	Documents     []*DocumentSummary `json:"documents"`
+	NextPageToken string             `json:"next_page_token"`
 }
 
 // DocumentSummary is a brief representation of a stored document.
 type DocumentSummary struct {
-	DocumentId string `json:"document_id"`
-	Filename   string `json:"filename"`
-	Status     string `json:"status"`
+	DocumentId    string          `json:"document_id"`
+	Filename      string          `json:"filename"`
+	Status        string          `json:"status"`
+	Processing    *ProcessingInfo `json:"processing,omitempty"`
+	FailureReason string          `json:"failure_reason,omitempty"`
 }
 
 // UpdateDataPointsRequest is the request for UpdateDataPoints.
 type UpdateDataPointsRequest struct {
-	DocumentId string            `json:"document_id"`
-	Results    map[string]string `json:"results"`
+	DocumentId string                      `json:"document_id"`
+	Results    map[string]string           `json:"results"`
+	Details    map[string]*DataPointResult `json:"details"`
+	TenantId   string                      `json:"tenant_id"`
+	Pages      int32                       `json:"pages"`
+	WorkerId   string                      `json:"worker_id"`
+	Events     []*TimelineEvent            `json:"events"`
+	Error      string                      `json:"error,omitempty"`
 }
 
 // UpdateDataPointsResponse is the response from UpdateDataPoints.
 type UpdateDataPointsResponse struct {
 	Status string `json:"status"`
 }
+
+// ReviewItem describes a document waiting in the human review queue.
+type ReviewItem struct {
+	DocumentId    string   `json:"document_id"`
+	Filename      string   `json:"filename"`
+	Revision      int32    `json:"revision"`
+	ReviewReasons []string `json:"review_reasons"`
+	ClaimedBy     string   `json:"claimed_by,omitempty"`
+	ClaimedAt     string   `json:"claimed_at,omitempty"`
+}
+
+// ListReviewQueueRequest is the request for ListReviewQueue.
+type ListReviewQueueRequest struct{}
+
+// ListReviewQueueResponse is the response from ListReviewQueue.
+type ListReviewQueueResponse struct {
+	Items []*ReviewItem `json:"items"`
+}
+
+// ClaimReviewRequest is the request for ClaimReview.
+type ClaimReviewRequest struct {
+	DocumentId string `json:"document_id"`
+	Reviewer   string `json:"reviewer"`
+}
+
+// ClaimReviewResponse is the response from ClaimReview.
+type ClaimReviewResponse struct {
+	Item *ReviewItem `json:"item"`
+}
+
+// ApproveReviewRequest is the request for ApproveReview. Corrections maps
+// data point names to the reviewer's value; an empty map approves the
+// current results as they are.
+type ApproveReviewRequest struct {
+	DocumentId  string            `json:"document_id"`
+	Reviewer    string            `json:"reviewer"`
+	Corrections map[string]string `json:"corrections"`
+}
+
+// ApproveReviewResponse is the response from ApproveReview.
+type ApproveReviewResponse struct {
+	Status   string `json:"status"`
+	Revision int32  `json:"revision"`
+}
+
+// ReleaseReviewRequest is the request for ReleaseReview.
+type ReleaseReviewRequest struct {
+	DocumentId string `json:"document_id"`
+	Reviewer   string `json:"reviewer"`
+}
+
+// ReleaseReviewResponse is the response from ReleaseReview.
+type ReleaseReviewResponse struct {
+	Status string `json:"status"`
+}
+
+// ResultRevision is one immutable snapshot of a document's results.
+type ResultRevision struct {
+	Revision  int32                       `json:"revision"`
+	Source    string                      `json:"source"`
+	Author    string                      `json:"author"`
+	CreatedAt string                      `json:"created_at"`
+	Results   map[string]string           `json:"results"`
+	Details   map[string]*DataPointResult `json:"details"`
+}
+
+// ListRevisionsRequest is the request for ListRevisions.
+type ListRevisionsRequest struct {
+	DocumentId string `json:"document_id"`
+}
+
+// ListRevisionsResponse is the response from ListRevisions.
+type ListRevisionsResponse struct {
+	Revisions []*ResultRevision `json:"revisions"`
+}
+
+// DeleteDocumentRequest is the request for DeleteDocument.
+type DeleteDocumentRequest struct {
+	DocumentId string `json:"document_id"`
+}
+
+// DeleteDocumentResponse is the response from DeleteDocument.
+type DeleteDocumentResponse struct {
+	Status string `json:"status"`
+}
+
+// ReprocessDocumentRequest is the request for ReprocessDocument. DataPoints
+// replaces the document's data points when non-empty.
+type ReprocessDocumentRequest struct {
+	DocumentId string   `json:"document_id"`
+	DataPoints []string `json:"data_points"`
+}
+
+// ReprocessDocumentResponse is the response from ReprocessDocument.
+type ReprocessDocumentResponse struct {
+	DocumentId string `json:"document_id"`
+	Status     string `json:"status"`
+}
+
+// UsageRecord is a tenant's metered usage for one day (Day set) or a period.
+type UsageRecord struct {
+	TenantId  string `json:"tenant_id"`
+	Day       string `json:"day,omitempty"`
+	Documents int64  `json:"documents"`
+	Pages     int64  `json:"pages"`
+	Bytes     int64  `json:"bytes"`
+}
+
+// UsageQuota is a tenant's monthly limit; zero fields are unlimited.
+type UsageQuota struct {
+	Documents int64 `json:"documents"`
+	Pages     int64 `json:"pages"`
+	Bytes     int64 `json:"bytes"`
+}
+
+// GetUsageRequest is the request for GetUsage. From and To are inclusive
+// YYYY-MM-DD days; empty bounds are open.
+type GetUsageRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// GetUsageResponse is the response from GetUsage.
+type GetUsageResponse struct {
+	TenantId    string         `json:"tenant_id"`
+	Records     []*UsageRecord `json:"records"`
+	MonthToDate *UsageRecord   `json:"month_to_date"`
+	Quota       *UsageQuota    `json:"quota"`
+}
+
+// ExportUsageRequest is the request for ExportUsage.
+type ExportUsageRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ExportUsageResponse is the response from ExportUsage.
+type ExportUsageResponse struct {
+	Records []*UsageRecord `json:"records"`
+}
+
+// TimelineEvent is one entry in a document's timeline. At is RFC 3339 with
+// fractional seconds; Detail holds event-specific values such as the Kafka
+// partition and offset or the NLP attempt number.
+type TimelineEvent struct {
+	Type   string            `json:"type"`
+	At     string            `json:"at"`
+	Actor  string            `json:"actor"`
+	Detail map[string]string `json:"detail,omitempty"`
+}
+
+// GetTimelineRequest is the request for GetTimeline.
+type GetTimelineRequest struct {
+	DocumentId string `json:"document_id"`
+}
+
+// GetTimelineResponse is the response from GetTimeline. Events are oldest
+// first.
+type GetTimelineResponse struct {
+	DocumentId string           `json:"document_id"`
+	Status     string           `json:"status"`
+	Events     []*TimelineEvent `json:"events"`
+}
+
+// ReportProgressRequest is the request for ReportProgress, sent by the
+// consumer when it picks up a document and as extraction proceeds.
+// StartedAt is when the worker picked the document up; Events are added to
+// the document's timeline.
+type ReportProgressRequest struct {
+	DocumentId string           `json:"document_id"`
+	TenantId   string           `json:"tenant_id"`
+	WorkerId   string           `json:"worker_id"`
+	StartedAt  string           `json:"started_at"`
+	Stage      string           `json:"stage"`
+	Message    string           `json:"message"`
+	Events     []*TimelineEvent `json:"events"`
+}
+
+// ReportProgressResponse is the response from ReportProgress.
+type ReportProgressResponse struct {
+	Status string `json:"status"`
+}
+
+// GetSweeperStatusRequest is the request for GetSweeperStatus.
+type GetSweeperStatusRequest struct{}
+
+// GetSweeperStatusResponse is the response from GetSweeperStatus: the
+// sweeper's settings, what it has done since startup, and the documents it
+// is watching. LastRun is RFC 3339 with fractional seconds.
+type GetSweeperStatusResponse struct {
+	Enabled         bool             `json:"enabled"`
+	SlaSeconds      int64            `json:"sla_seconds"`
+	IntervalSeconds int64            `json:"interval_seconds"`
+	MaxRepublish    int32            `json:"max_republish"`
+	LastRun         string           `json:"last_run,omitempty"`
+	Stuck           int32            `json:"stuck"`
+	Republished     int64            `json:"republished"`
+	TimedOut        int64            `json:"timed_out"`
+	Documents       []*SweptDocument `json:"documents"`
+}
+
+// SweptDocument is a document the sweeper is watching: overdue now,
+// already republished, or timed out.
+type SweptDocument struct {
+	DocumentId    string `json:"document_id"`
+	TenantId      string `json:"tenant_id"`
+	Status        string `json:"status"`
+	LastActivity  string `json:"last_activity"`
+	IdleSeconds   int64  `json:"idle_seconds"`
+	Overdue       bool   `json:"overdue"`
+	Republished   int32  `json:"republished"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+// DocumentFilter selects documents for the admin operations, across every
+// tenant. Set fields must all match; OlderThan is a Go duration such as
+// "72h" compared with the upload time.
+type DocumentFilter struct {
+	DocumentIds []string `json:"document_ids"`
+	TenantId    string   `json:"tenant_id"`
+	Status      string   `json:"status"`
+	OlderThan   string   `json:"older_than"`
+	Unpublished bool     `json:"unpublished"`
+}
+
+// GetDocumentCountsRequest is the request for GetDocumentCounts.
+type GetDocumentCountsRequest struct {
+	TenantId string `json:"tenant_id"`
+}
+
+// GetDocumentCountsResponse is the response from GetDocumentCounts.
+type GetDocumentCountsResponse struct {
+	Total    int64            `json:"total"`
+	ByStatus map[string]int64 `json:"by_status"`
+	Tenants  []*TenantCounts  `json:"tenants"`
+}
+
+// TenantCounts is one tenant's documents by status.
+type TenantCounts struct {
+	TenantId string           `json:"tenant_id"`
+	Total    int64            `json:"total"`
+	ByStatus map[string]int64 `json:"by_status"`
+}
+
+// RequeueDocumentsRequest is the request for RequeueDocuments. With DryRun
+// the matching documents are reported but left alone.
+type RequeueDocumentsRequest struct {
+	Filter *DocumentFilter `json:"filter"`
+	DryRun bool            `json:"dry_run"`
+}
+
+// RequeueDocumentsResponse is the response from RequeueDocuments. Failed
+// lists the documents that could not be published.
+type RequeueDocumentsResponse struct {
+	DocumentIds []string `json:"document_ids"`
+	Requeued    int32    `json:"requeued"`
+	Failed      []string `json:"failed"`
+}
+
+// PurgeDocumentsRequest is the request for PurgeDocuments. With DryRun the
+// matching documents are reported but left alone.
+type PurgeDocumentsRequest struct {
+	Filter *DocumentFilter `json:"filter"`
+	DryRun bool            `json:"dry_run"`
+}
+
+// PurgeDocumentsResponse is the response from PurgeDocuments.
+type PurgeDocumentsResponse struct {
+	DocumentIds []string `json:"document_ids"`
+	Purged      int32    `json:"purged"`
+}
+
+// ListOutboxRequest is the request for ListOutbox.
+type ListOutboxRequest struct {
+	TenantId string `json:"tenant_id"`
+}
+
+// ListOutboxResponse is the response from ListOutbox, oldest failure
+// first.
+type ListOutboxResponse struct {
+	Entries []*OutboxEntry `json:"entries"`
+}
+
+// OutboxEntry is a stored document whose upload event has not reached
+// Kafka. FailedAt is RFC 3339 with fractional seconds.
+type OutboxEntry struct {
+	DocumentId string `json:"document_id"`
+	TenantId   string `json:"tenant_id"`
+	Filename   string `json:"filename"`
+	Status     string `json:"status"`
+	Error      string `json:"error"`
+	FailedAt   string `json:"failed_at"`
+}
+
+// ListDeadLettersRequest is the request for ListDeadLetters. Both filters
+// are optional.
+type ListDeadLettersRequest struct {
+	TenantId string `json:"tenant_id"`
+	Reason   string `json:"reason"`
+}
+
+// ListDeadLettersResponse is the response from ListDeadLetters, by
+// partition, oldest first.
+type ListDeadLettersResponse struct {
+	Topic   string             `json:"topic"`
+	Entries []*DeadLetterEntry `json:"entries"`
+}
+
+// DeadLetterEntry is an upload event the consumer could not process.
+// Partition and Offset locate it on the dead-letter topic, the Source fields
+// on the uploads topic. Reason is invalid_message, nlp or callback. FailedAt
+// and ReplayedAt are RFC 3339 with fractional seconds; ReplayedAt is empty
+// until the entry is replayed.
+type DeadLetterEntry struct {
+	Partition       int32  `json:"partition"`
+	Offset          int64  `json:"offset"`
+	Reason          string `json:"reason"`
+	Error           string `json:"error"`
+	SourceTopic     string `json:"source_topic"`
+	SourcePartition int32  `json:"source_partition"`
+	SourceOffset    int64  `json:"source_offset"`
+	FailedAt        string `json:"failed_at"`
+	WorkerId        string `json:"worker_id"`
+	DocumentId      string `json:"document_id"`
+	TenantId        string `json:"tenant_id"`
+	ReplayedAt      string `json:"replayed_at,omitempty"`
+}
+
+// DeadLetterRef names a message on the dead-letter topic.
+type DeadLetterRef struct {
+	Partition int32 `json:"partition"`
+	Offset    int64 `json:"offset"`
+}
+
+// ReplayDeadLettersRequest is the request for ReplayDeadLetters. Every
+// selector that is set must match; at least one is required.
+type ReplayDeadLettersRequest struct {
+	Entries         []*DeadLetterRef `json:"entries"`
+	TenantId        string           `json:"tenant_id"`
+	Reason          string           `json:"reason"`
+	IncludeReplayed bool             `json:"include_replayed"`
+	DryRun          bool             `json:"dry_run"`
+}
+
+// ReplayDeadLettersResponse is the response from ReplayDeadLetters.
+type ReplayDeadLettersResponse struct {
+	Entries  []*DeadLetterRef `json:"entries"`
+	Replayed int32            `json:"replayed"`
+	Failed   []*DeadLetterRef `json:"failed"`
+}
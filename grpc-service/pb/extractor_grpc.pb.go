@@ -19,8 +19,8 @@ func init() {
 // jsonCodec serialises gRPC messages as JSON.
 type jsonCodec struct{}
 
-func (jsonCodec) Name() string                        { return "proto" }
-func (jsonCodec) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+func (jsonCodec) Name() string                            { return "proto" }
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)   { return json.Marshal(v) }
 func (jsonCodec) Unmarshal(b []byte, v interface{}) error { return json.Unmarshal(b, v) }
 
 // ExtractorServiceServer is the server-side interface for ExtractorService.
@@ -29,6 +29,24 @@ type ExtractorServiceServer interface {
 	GetDataPoints(context.Context, *GetDataPointsRequest) (*GetDataPointsResponse, error)
 	ListDocuments(context.Context, *ListDocumentsRequest) (*ListDocumentsResponse, error)
 	UpdateDataPoints(context.Context, *UpdateDataPointsRequest) (*UpdateDataPointsResponse, error)
+	ListReviewQueue(context.Context, *ListReviewQueueRequest) (*ListReviewQueueResponse, error)
+	ClaimReview(context.Context, *ClaimReviewRequest) (*ClaimReviewResponse, error)
+	ApproveReview(context.Context, *ApproveReviewRequest) (*ApproveReviewResponse, error)
+	ReleaseReview(context.Context, *ReleaseReviewRequest) (*ReleaseReviewResponse, error)
+	ListRevisions(context.Context, *ListRevisionsRequest) (*ListRevisionsResponse, error)
+	DeleteDocument(context.Context, *DeleteDocumentRequest) (*DeleteDocumentResponse, error)
+	ReprocessDocument(context.Context, *ReprocessDocumentRequest) (*ReprocessDocumentResponse, error)
+	GetUsage(context.Context, *GetUsageRequest) (*GetUsageResponse, error)
+	ExportUsage(context.Context, *ExportUsageRequest) (*ExportUsageResponse, error)
+	GetTimeline(context.Context, *GetTimelineRequest) (*GetTimelineResponse, error)
+	ReportProgress(context.Context, *ReportProgressRequest) (*ReportProgressResponse, error)
+	GetSweeperStatus(context.Context, *GetSweeperStatusRequest) (*GetSweeperStatusResponse, error)
+	GetDocumentCounts(context.Context, *GetDocumentCountsRequest) (*GetDocumentCountsResponse, error)
+	RequeueDocuments(context.Context, *RequeueDocumentsRequest) (*RequeueDocumentsResponse, error)
+	PurgeDocuments(context.Context, *PurgeDocumentsRequest) (*PurgeDocumentsResponse, error)
+	ListOutbox(context.Context, *ListOutboxRequest) (*ListOutboxResponse, error)
+	ListDeadLetters(context.Context, *ListDeadLettersRequest) (*ListDeadLettersResponse, error)
+	ReplayDeadLetters(context.Context, *ReplayDeadLettersRequest) (*ReplayDeadLettersResponse, error)
 }
 
 // UnimplementedExtractorServiceServer provides default (stub) implementations.
@@ -46,12 +64,277 @@ func (UnimplementedExtractorServiceServer) ListDocuments(_ context.Context, _ *L
 func (UnimplementedExtractorServiceServer) UpdateDataPoints(_ context.Context, _ *UpdateDataPointsRequest) (*UpdateDataPointsResponse, error) {
 	return nil, nil
 }
+func (UnimplementedExtractorServiceServer) ListReviewQueue(_ context.Context, _ *ListReviewQueueRequest) (*ListReviewQueueResponse, error) {
+	return nil, nil
+}
+func (UnimplementedExtractorServiceServer) ClaimReview(_ context.Context, _ *ClaimReviewRequest) (*ClaimReviewResponse, error) {
+	return nil, nil
+}
+func (UnimplementedExtractorServiceServer) ApproveReview(_ context.Context, _ *ApproveReviewRequest) (*ApproveReviewResponse, error) {
+	return nil, nil
+}
+func (UnimplementedExtractorServiceServer) ReleaseReview(_ context.Context, _ *ReleaseReviewRequest) (*ReleaseReviewResponse, error) {
+	return nil, nil
+}
+func (UnimplementedExtractorServiceServer) ListRevisions(_ context.Context, _ *ListRevisionsRequest) (*ListRevisionsResponse, error) {
+	return nil, nil
+}
+func (UnimplementedExtractorServiceServer) DeleteDocument(_ context.Context, _ *DeleteDocumentRequest) (*DeleteDocumentResponse, error) {
+	return nil, nil
+}
+func (UnimplementedExtractorServiceServer) ReprocessDocument(_ context.Context, _ *ReprocessDocumentRequest) (*ReprocessDocumentResponse, error) {
+	return nil, nil
+}
+func (UnimplementedExtractorServiceServer) GetUsage(_ context.Context, _ *GetUsageRequest) (*GetUsageResponse, error) {
+	return nil, nil
+}
+func (UnimplementedExtractorServiceServer) ExportUsage(_ context.Context, _ *ExportUsageRequest) (*ExportUsageResponse, error) {
+	return nil, nil
+}
+func (UnimplementedExtractorServiceServer) GetTimeline(_ context.Context, _ *GetTimelineRequest) (*GetTimelineResponse, error) {
+	return nil, nil
+}
+func (UnimplementedExtractorServiceServer) ReportProgress(_ context.Context, _ *ReportProgressRequest) (*ReportProgressResponse, error) {
+	return nil, nil
+}
+func (UnimplementedExtractorServiceServer) GetSweeperStatus(_ context.Context, _ *GetSweeperStatusRequest) (*GetSweeperStatusResponse, error) {
+	return nil, nil
+}
+func (UnimplementedExtractorServiceServer) GetDocumentCounts(_ context.Context, _ *GetDocumentCountsRequest) (*GetDocumentCountsResponse, error) {
+	return nil, nil
+}
+func (UnimplementedExtractorServiceServer) RequeueDocuments(_ context.Context, _ *RequeueDocumentsRequest) (*RequeueDocumentsResponse, error) {
+	return nil, nil
+}
+func (UnimplementedExtractorServiceServer) PurgeDocuments(_ context.Context, _ *PurgeDocumentsRequest) (*PurgeDocumentsResponse, error) {
+	return nil, nil
+}
+func (UnimplementedExtractorServiceServer) ListOutbox(_ context.Context, _ *ListOutboxRequest) (*ListOutboxResponse, error) {
+	return nil, nil
+}
+func (UnimplementedExtractorServiceServer) ListDeadLetters(_ context.Context, _ *ListDeadLettersRequest) (*ListDeadLettersResponse, error) {
+	return nil, nil
+}
+func (UnimplementedExtractorServiceServer) ReplayDeadLetters(_ context.Context, _ *ReplayDeadLettersRequest) (*ReplayDeadLettersResponse, error) {
+	return nil, nil
+}
 
 // RegisterExtractorServiceServer registers srv with the given gRPC server.
 func RegisterExtractorServiceServer(s *grpc.Server, srv ExtractorServiceServer) {
 	s.RegisterService(&ExtractorService_ServiceDesc, srv)
 }
 
+// ExtractorServiceClient is the client-side interface for ExtractorService.
+type ExtractorServiceClient interface {
+	UploadDocument(ctx context.Context, in *UploadDocumentRequest, opts ...grpc.CallOption) (*UploadDocumentResponse, error)
+	GetDataPoints(ctx context.Context, in *GetDataPointsRequest, opts ...grpc.CallOption) (*GetDataPointsResponse, error)
+	ListDocuments(ctx context.Context, in *ListDocumentsRequest, opts ...grpc.CallOption) (*ListDocumentsResponse, error)
+	UpdateDataPoints(ctx context.Context, in *UpdateDataPointsRequest, opts ...grpc.CallOption) (*UpdateDataPointsResponse, error)
+	ListReviewQueue(ctx context.Context, in *ListReviewQueueRequest, opts ...grpc.CallOption) (*ListReviewQueueResponse, error)
+	ClaimReview(ctx context.Context, in *ClaimReviewRequest, opts ...grpc.CallOption) (*ClaimReviewResponse, error)
+	ApproveReview(ctx context.Context, in *ApproveReviewRequest, opts ...grpc.CallOption) (*ApproveReviewResponse, error)
+	ReleaseReview(ctx context.Context, in *ReleaseReviewRequest, opts ...grpc.CallOption) (*ReleaseReviewResponse, error)
+	ListRevisions(ctx context.Context, in *ListRevisionsRequest, opts ...grpc.CallOption) (*ListRevisionsResponse, error)
+	DeleteDocument(ctx context.Context, in *DeleteDocumentRequest, opts ...grpc.CallOption) (*DeleteDocumentResponse, error)
+	ReprocessDocument(ctx context.Context, in *ReprocessDocumentRequest, opts ...grpc.CallOption) (*ReprocessDocumentResponse, error)
+	GetUsage(ctx context.Context, in *GetUsageRequest, opts ...grpc.CallOption) (*GetUsageResponse, error)
+	ExportUsage(ctx context.Context, in *ExportUsageRequest, opts ...grpc.CallOption) (*ExportUsageResponse, error)
+	GetTimeline(ctx context.Context, in *GetTimelineRequest, opts ...grpc.CallOption) (*GetTimelineResponse, error)
+	ReportProgress(ctx context.Context, in *ReportProgressRequest, opts ...grpc.CallOption) (*ReportProgressResponse, error)
+	GetSweeperStatus(ctx context.Context, in *GetSweeperStatusRequest, opts ...grpc.CallOption) (*GetSweeperStatusResponse, error)
+	GetDocumentCounts(ctx context.Context, in *GetDocumentCountsRequest, opts ...grpc.CallOption) (*GetDocumentCountsResponse, error)
+	RequeueDocuments(ctx context.Context, in *RequeueDocumentsRequest, opts ...grpc.CallOption) (*RequeueDocumentsResponse, error)
+	PurgeDocuments(ctx context.Context, in *PurgeDocumentsRequest, opts ...grpc.CallOption) (*PurgeDocumentsResponse, error)
+	ListOutbox(ctx context.Context, in *ListOutboxRequest, opts ...grpc.CallOption) (*ListOutboxResponse, error)
+	ListDeadLetters(ctx context.Context, in *ListDeadLettersRequest, opts ...grpc.CallOption) (*ListDeadLettersResponse, error)
+	ReplayDeadLetters(ctx context.Context, in *ReplayDeadLettersRequest, opts ...grpc.CallOption) (*ReplayDeadLettersResponse, error)
+}
+
+type extractorServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+// NewExtractorServiceClient returns a client that calls ExtractorService over cc.
+func NewExtractorServiceClient(cc grpc.ClientConnInterface) ExtractorServiceClient {
+	return &extractorServiceClient{cc: cc}
+}
+
+func (c *extractorServiceClient) UploadDocument(ctx context.Context, in *UploadDocumentRequest, opts ...grpc.CallOption) (*UploadDocumentResponse, error) {
+	out := new(UploadDocumentResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/UploadDocument", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) GetDataPoints(ctx context.Context, in *GetDataPointsRequest, opts ...grpc.CallOption) (*GetDataPointsResponse, error) {
+	out := new(GetDataPointsResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/GetDataPoints", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) ListDocuments(ctx context.Context, in *ListDocumentsRequest, opts ...grpc.CallOption) (*ListDocumentsResponse, error) {
+	out := new(ListDocumentsResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/ListDocuments", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) UpdateDataPoints(ctx context.Context, in *UpdateDataPointsRequest, opts ...grpc.CallOption) (*UpdateDataPointsResponse, error) {
+	out := new(UpdateDataPointsResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/UpdateDataPoints", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) ListReviewQueue(ctx context.Context, in *ListReviewQueueRequest, opts ...grpc.CallOption) (*ListReviewQueueResponse, error) {
+	out := new(ListReviewQueueResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/ListReviewQueue", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) ClaimReview(ctx context.Context, in *ClaimReviewRequest, opts ...grpc.CallOption) (*ClaimReviewResponse, error) {
+	out := new(ClaimReviewResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/ClaimReview", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) ApproveReview(ctx context.Context, in *ApproveReviewRequest, opts ...grpc.CallOption) (*ApproveReviewResponse, error) {
+	out := new(ApproveReviewResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/ApproveReview", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) ReleaseReview(ctx context.Context, in *ReleaseReviewRequest, opts ...grpc.CallOption) (*ReleaseReviewResponse, error) {
+	out := new(ReleaseReviewResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/ReleaseReview", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) ListRevisions(ctx context.Context, in *ListRevisionsRequest, opts ...grpc.CallOption) (*ListRevisionsResponse, error) {
+	out := new(ListRevisionsResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/ListRevisions", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) DeleteDocument(ctx context.Context, in *DeleteDocumentRequest, opts ...grpc.CallOption) (*DeleteDocumentResponse, error) {
+	out := new(DeleteDocumentResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/DeleteDocument", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) ReprocessDocument(ctx context.Context, in *ReprocessDocumentRequest, opts ...grpc.CallOption) (*ReprocessDocumentResponse, error) {
+	out := new(ReprocessDocumentResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/ReprocessDocument", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) GetUsage(ctx context.Context, in *GetUsageRequest, opts ...grpc.CallOption) (*GetUsageResponse, error) {
+	out := new(GetUsageResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/GetUsage", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) ExportUsage(ctx context.Context, in *ExportUsageRequest, opts ...grpc.CallOption) (*ExportUsageResponse, error) {
+	out := new(ExportUsageResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/ExportUsage", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) GetTimeline(ctx context.Context, in *GetTimelineRequest, opts ...grpc.CallOption) (*GetTimelineResponse, error) {
+	out := new(GetTimelineResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/GetTimeline", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) ReportProgress(ctx context.Context, in *ReportProgressRequest, opts ...grpc.CallOption) (*ReportProgressResponse, error) {
+	out := new(ReportProgressResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/ReportProgress", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) GetSweeperStatus(ctx context.Context, in *GetSweeperStatusRequest, opts ...grpc.CallOption) (*GetSweeperStatusResponse, error) {
+	out := new(GetSweeperStatusResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/GetSweeperStatus", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) GetDocumentCounts(ctx context.Context, in *GetDocumentCountsRequest, opts ...grpc.CallOption) (*GetDocumentCountsResponse, error) {
+	out := new(GetDocumentCountsResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/GetDocumentCounts", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) RequeueDocuments(ctx context.Context, in *RequeueDocumentsRequest, opts ...grpc.CallOption) (*RequeueDocumentsResponse, error) {
+	out := new(RequeueDocumentsResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/RequeueDocuments", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) PurgeDocuments(ctx context.Context, in *PurgeDocumentsRequest, opts ...grpc.CallOption) (*PurgeDocumentsResponse, error) {
+	out := new(PurgeDocumentsResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/PurgeDocuments", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) ListOutbox(ctx context.Context, in *ListOutboxRequest, opts ...grpc.CallOption) (*ListOutboxResponse, error) {
+	out := new(ListOutboxResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/ListOutbox", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) ListDeadLetters(ctx context.Context, in *ListDeadLettersRequest, opts ...grpc.CallOption) (*ListDeadLettersResponse, error) {
+	out := new(ListDeadLettersResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/ListDeadLetters", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *extractorServiceClient) ReplayDeadLetters(ctx context.Context, in *ReplayDeadLettersRequest, opts ...grpc.CallOption) (*ReplayDeadLettersResponse, error) {
+	out := new(ReplayDeadLettersResponse)
+	if err := c.cc.Invoke(ctx, "/extractor.ExtractorService/ReplayDeadLetters", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // --- method handlers ---------------------------------------------------------
 
 func _UploadDocument_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
@@ -114,6 +397,276 @@ func _UpdateDataPoints_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ListReviewQueue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListReviewQueueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtractorServiceServer).ListReviewQueue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/extractor.ExtractorService/ListReviewQueue"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtractorServiceServer).ListReviewQueue(ctx, req.(*ListReviewQueueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ClaimReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClaimReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtractorServiceServer).ClaimReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/extractor.ExtractorService/ClaimReview"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtractorServiceServer).ClaimReview(ctx, req.(*ClaimReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ApproveReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApproveReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtractorServiceServer).ApproveReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/extractor.ExtractorService/ApproveReview"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtractorServiceServer).ApproveReview(ctx, req.(*ApproveReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReleaseReview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseReviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtractorServiceServer).ReleaseReview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/extractor.ExtractorService/ReleaseReview"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtractorServiceServer).ReleaseReview(ctx, req.(*ReleaseReviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ListRevisions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRevisionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtractorServiceServer).ListRevisions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/extractor.ExtractorService/ListRevisions"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtractorServiceServer).ListRevisions(ctx, req.(*ListRevisionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _DeleteDocument_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteDocumentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtractorServiceServer).DeleteDocument(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/extractor.ExtractorService/DeleteDocument"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtractorServiceServer).DeleteDocument(ctx, req.(*DeleteDocumentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReprocessDocument_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReprocessDocumentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtractorServiceServer).ReprocessDocument(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/extractor.ExtractorService/ReprocessDocument"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtractorServiceServer).ReprocessDocument(ctx, req.(*ReprocessDocumentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GetUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUsageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtractorServiceServer).GetUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/extractor.ExtractorService/GetUsage"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtractorServiceServer).GetUsage(ctx, req.(*GetUsageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExportUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportUsageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtractorServiceServer).ExportUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/extractor.ExtractorService/ExportUsage"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtractorServiceServer).ExportUsage(ctx, req.(*ExportUsageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GetTimeline_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTimelineRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtractorServiceServer).GetTimeline(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/extractor.ExtractorService/GetTimeline"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtractorServiceServer).GetTimeline(ctx, req.(*GetTimelineRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReportProgress_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportProgressRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtractorServiceServer).ReportProgress(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/extractor.ExtractorService/ReportProgress"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtractorServiceServer).ReportProgress(ctx, req.(*ReportProgressRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GetSweeperStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSweeperStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtractorServiceServer).GetSweeperStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/extractor.ExtractorService/GetSweeperStatus"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtractorServiceServer).GetSweeperStatus(ctx, req.(*GetSweeperStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _GetDocumentCounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDocumentCountsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtractorServiceServer).GetDocumentCounts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/extractor.ExtractorService/GetDocumentCounts"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtractorServiceServer).GetDocumentCounts(ctx, req.(*GetDocumentCountsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RequeueDocuments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RequeueDocumentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtractorServiceServer).RequeueDocuments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/extractor.ExtractorService/RequeueDocuments"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtractorServiceServer).RequeueDocuments(ctx, req.(*RequeueDocumentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PurgeDocuments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeDocumentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtractorServiceServer).PurgeDocuments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/extractor.ExtractorService/PurgeDocuments"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtractorServiceServer).PurgeDocuments(ctx, req.(*PurgeDocumentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ListOutbox_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListOutboxRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtractorServiceServer).ListOutbox(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/extractor.ExtractorService/ListOutbox"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtractorServiceServer).ListOutbox(ctx, req.(*ListOutboxRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ListDeadLetters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeadLettersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtractorServiceServer).ListDeadLetters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/extractor.ExtractorService/ListDeadLetters"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtractorServiceServer).ListDeadLetters(ctx, req.(*ListDeadLettersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReplayDeadLetters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplayDeadLettersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExtractorServiceServer).ReplayDeadLetters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/extractor.ExtractorService/ReplayDeadLetters"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExtractorServiceServer).ReplayDeadLetters(ctx, req.(*ReplayDeadLettersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ExtractorService_ServiceDesc is the grpc.ServiceDesc for ExtractorService.
 var ExtractorService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "extractor.ExtractorService",
@@ -123,6 +676,24 @@ var ExtractorService_ServiceDesc = grpc.ServiceDesc{
 		{MethodName: "GetDataPoints", Handler: _GetDataPoints_Handler},
 		{MethodName: "ListDocuments", Handler: _ListDocuments_Handler},
 		{MethodName: "UpdateDataPoints", Handler: _UpdateDataPoints_Handler},
+		{MethodName: "ListReviewQueue", Handler: _ListReviewQueue_Handler},
+		{MethodName: "ClaimReview", Handler: _ClaimReview_Handler},
+		{MethodName: "ApproveReview", Handler: _ApproveReview_Handler},
+		{MethodName: "ReleaseReview", Handler: _ReleaseReview_Handler},
+		{MethodName: "ListRevisions", Handler: _ListRevisions_Handler},
+		{MethodName: "DeleteDocument", Handler: _DeleteDocument_Handler},
+		{MethodName: "ReprocessDocument", Handler: _ReprocessDocument_Handler},
+		{MethodName: "GetUsage", Handler: _GetUsage_Handler},
+		{MethodName: "ExportUsage", Handler: _ExportUsage_Handler},
+		{MethodName: "GetTimeline", Handler: _GetTimeline_Handler},
+		{MethodName: "ReportProgress", Handler: _ReportProgress_Handler},
+		{MethodName: "GetSweeperStatus", Handler: _GetSweeperStatus_Handler},
+		{MethodName: "GetDocumentCounts", Handler: _GetDocumentCounts_Handler},
+		{MethodName: "RequeueDocuments", Handler: _RequeueDocuments_Handler},
+		{MethodName: "PurgeDocuments", Handler: _PurgeDocuments_Handler},
+		{MethodName: "ListOutbox", Handler: _ListOutbox_Handler},
+		{MethodName: "ListDeadLetters", Handler: _ListDeadLetters_Handler},
+		{MethodName: "ReplayDeadLetters", Handler: _ReplayDeadLetters_Handler},
 	},
 	Streams: []grpc.StreamDesc{},
 }
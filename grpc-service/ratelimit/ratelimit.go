@@ -0,0 +1,192 @@
+// Package ratelimit throttles API callers with token buckets.
+//
+// A Limiter keeps one bucket per key. Keys are derived from the caller's
+// identity (see package auth): per caller, i.e. per API key or JWT subject,
+// or per tenant. Unauthenticated callers are keyed by client IP.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/auth"
+)
+
+// Scope selects what a Limiter's buckets are keyed by.
+type Scope string
+
+const (
+	// ScopeCaller keys buckets by tenant and subject.
+	ScopeCaller Scope = "caller"
+	// ScopeTenant shares one bucket between all callers of a tenant.
+	ScopeTenant Scope = "tenant"
+)
+
+// idleSweep is how often buckets that have refilled completely are dropped.
+const idleSweep = time.Minute
+
+// Limiter is a set of token buckets refilled at Rate tokens per second up to
+// Burst tokens.
+type Limiter struct {
+	scope Scope
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New returns a Limiter allowing rate requests per second with bursts of up
+// to burst requests per key.
+func New(scope Scope, rate float64, burst int) (*Limiter, error) {
+	if scope != ScopeCaller && scope != ScopeTenant {
+		return nil, fmt.Errorf("ratelimit: unknown scope %q", scope)
+	}
+	if rate <= 0 || burst < 1 {
+		return nil, fmt.Errorf("ratelimit: rate must be positive and burst at least 1")
+	}
+	return &Limiter{
+		scope:   scope,
+		rate:    rate,
+		burst:   float64(burst),
+		now:     time.Now,
+		buckets: make(map[string]*bucket),
+	}, nil
+}
+
+// Allow takes a token from key's bucket. When the bucket is empty it reports
+// how long until a token is available.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) > idleSweep {
+		for k, b := range l.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// key derives the bucket key for a caller.
+func (l *Limiter) key(ctx context.Context, remoteAddr string) string {
+	id, ok := auth.FromContext(ctx)
+	switch {
+	case l.scope == ScopeTenant:
+		return "tenant:" + auth.TenantFromContext(ctx)
+	case ok:
+		return "caller:" + auth.TenantFromContext(ctx) + "/" + id.Subject
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	return "ip:" + host
+}
+
+// check consults every limiter and returns the longest wait among those that
+// refused the call.
+func check(ctx context.Context, remoteAddr string, limiters []*Limiter) (bool, time.Duration) {
+	allowed, wait := true, time.Duration(0)
+	for _, l := range limiters {
+		if ok, d := l.Allow(l.key(ctx, remoteAddr)); !ok {
+			allowed = false
+			if d > wait {
+				wait = d
+			}
+		}
+	}
+	return allowed, wait
+}
+
+// retryAfterSeconds rounds d up to whole seconds, as Retry-After requires.
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// Middleware rejects requests over any limiter's rate with 429 Too Many
+// Requests and a Retry-After header. It must run after auth.Middleware so the
+// caller's identity is known.
+func Middleware(limiters []*Limiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ok, wait := check(r.Context(), r.RemoteAddr, limiters); !ok {
+			w.Header().Set("Retry-After", retryAfterSeconds(wait))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func peerAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+func exhausted(ctx context.Context, wait time.Duration) error {
+	secs := retryAfterSeconds(wait)
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", secs))
+	return status.Errorf(codes.ResourceExhausted, "rate limit exceeded; retry after %ss", secs)
+}
+
+// UnaryServerInterceptor rejects unary calls over any limiter's rate with
+// ResourceExhausted and a "retry-after" header. Chain it after the auth
+// interceptor.
+func UnaryServerInterceptor(limiters []*Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if ok, wait := check(ctx, peerAddr(ctx), limiters); !ok {
+			return nil, exhausted(ctx, wait)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is the streaming counterpart of
+// UnaryServerInterceptor; each stream counts as one request.
+func StreamServerInterceptor(limiters []*Limiter) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		if ok, wait := check(ctx, peerAddr(ctx), limiters); !ok {
+			return exhausted(ctx, wait)
+		}
+		return handler(srv, ss)
+	}
+}
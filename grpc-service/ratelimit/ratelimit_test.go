@@ -0,0 +1,184 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/auth"
+)
+
+// clock is a fake time source for a Limiter.
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestLimiter(t *testing.T, scope Scope, rate float64, burst int) (*Limiter, *clock) {
+	t.Helper()
+	l, err := New(scope, rate, burst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &clock{t: time.Unix(1_800_000_000, 0)}
+	l.now = c.now
+	return l, c
+}
+
+func TestAllow(t *testing.T) {
+	type step struct {
+		after    time.Duration // clock advance before the call
+		key      string
+		want     bool
+		wantWait time.Duration
+	}
+	tests := []struct {
+		name  string
+		rate  float64
+		burst int
+		steps []step
+	}{
+		{"burst then refused", 1, 3, []step{
+			{0, "a", true, 0},
+			{0, "a", true, 0},
+			{0, "a", true, 0},
+			{0, "a", false, time.Second},
+		}},
+		{"refill one token", 2, 1, []step{
+			{0, "a", true, 0},
+			{0, "a", false, 500 * time.Millisecond},
+			{200 * time.Millisecond, "a", false, 300 * time.Millisecond},
+			{300 * time.Millisecond, "a", true, 0},
+			{0, "a", false, 500 * time.Millisecond},
+		}},
+		{"refill is capped at burst", 10, 2, []step{
+			{0, "a", true, 0},
+			{0, "a", true, 0},
+			{time.Hour, "a", true, 0},
+			{0, "a", true, 0},
+			{0, "a", false, 100 * time.Millisecond},
+		}},
+		{"fractional rate", 0.5, 1, []step{
+			{0, "a", true, 0},
+			{time.Second, "a", false, time.Second},
+			{time.Second, "a", true, 0},
+		}},
+		{"keys have their own buckets", 1, 1, []step{
+			{0, "a", true, 0},
+			{0, "a", false, time.Second},
+			{0, "b", true, 0},
+			{0, "b", false, time.Second},
+		}},
+		{"refused calls take no token", 1, 1, []step{
+			{0, "a", true, 0},
+			{0, "a", false, time.Second},
+			{0, "a", false, time.Second},
+			{time.Second, "a", true, 0},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, c := newTestLimiter(t, ScopeCaller, tt.rate, tt.burst)
+			for i, s := range tt.steps {
+				c.advance(s.after)
+				ok, wait := l.Allow(s.key)
+				if ok != s.want || wait != s.wantWait {
+					t.Fatalf("step %d: Allow(%q) = %v, %v; want %v, %v", i, s.key, ok, wait, s.want, s.wantWait)
+				}
+			}
+		})
+	}
+}
+
+func TestAllowDropsIdleBuckets(t *testing.T) {
+	l, c := newTestLimiter(t, ScopeCaller, 1, 2)
+	l.Allow("a")
+	l.Allow("b")
+	l.Allow("b")
+	c.advance(idleSweep + time.Second)
+	l.Allow("c")
+	if _, ok := l.buckets["a"]; ok {
+		t.Error("full bucket a was kept")
+	}
+	if _, ok := l.buckets["b"]; ok {
+		t.Error("full bucket b was kept")
+	}
+	if _, ok := l.buckets["c"]; !ok {
+		t.Error("bucket c was dropped")
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		scope   Scope
+		rate    float64
+		burst   int
+		wantErr bool
+	}{
+		{"caller", ScopeCaller, 1, 1, false},
+		{"tenant", ScopeTenant, 0.1, 5, false},
+		{"unknown scope", "ip", 1, 1, true},
+		{"zero rate", ScopeCaller, 0, 1, true},
+		{"zero burst", ScopeCaller, 1, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.scope, tt.rate, tt.burst); (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKey(t *testing.T) {
+	alice := auth.NewContext(context.Background(), &auth.Identity{Subject: "alice", Tenant: "acme"})
+	tests := []struct {
+		name  string
+		scope Scope
+		ctx   context.Context
+		addr  string
+		want  string
+	}{
+		{"caller", ScopeCaller, alice, "10.0.0.1:5000", "caller:acme/alice"},
+		{"anonymous caller", ScopeCaller, context.Background(), "10.0.0.1:5000", "ip:10.0.0.1"},
+		{"address without port", ScopeCaller, context.Background(), "10.0.0.1", "ip:10.0.0.1"},
+		{"tenant", ScopeTenant, alice, "10.0.0.1:5000", "tenant:acme"},
+		{"anonymous tenant", ScopeTenant, context.Background(), "10.0.0.1:5000", "tenant:" + auth.DefaultTenant},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := newTestLimiter(t, tt.scope, 1, 1)
+			if got := l.key(tt.ctx, tt.addr); got != tt.want {
+				t.Errorf("key = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	slow, _ := newTestLimiter(t, ScopeCaller, 0.25, 1)
+	fast, _ := newTestLimiter(t, ScopeCaller, 10, 1)
+	h := Middleware([]*Limiter{fast, slow}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		want       int
+		retryAfter string
+	}{
+		{http.StatusNoContent, ""},
+		// The longest wait of the limiters that refused wins.
+		{http.StatusTooManyRequests, "4"},
+	}
+	for i, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/documents", nil))
+		if w.Code != tt.want || w.Header().Get("Retry-After") != tt.retryAfter {
+			t.Errorf("request %d: status %d, Retry-After %q; want %d, %q",
+				i, w.Code, w.Header().Get("Retry-After"), tt.want, tt.retryAfter)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/logging"
+)
+
+// grpcRequestID returns ctx carrying the caller's x-request-id, or a new
+// one, and sends it back in the response headers.
+func grpcRequestID(ctx context.Context) context.Context {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(logging.RequestIDKey); len(v) > 0 {
+			id = v[0]
+		}
+	}
+	if !logging.ValidRequestID(id) {
+		id = logging.NewRequestID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(logging.RequestIDKey, id))
+	return logging.WithRequestID(ctx, id)
+}
+
+// requestIDUnaryInterceptor gives every unary gRPC call a request ID.
+func requestIDUnaryInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(grpcRequestID(ctx), req)
+}
+
+// requestIDStreamInterceptor gives every streaming gRPC call a request ID.
+func requestIDStreamInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &contextStream{ServerStream: ss, ctx: grpcRequestID(ss.Context())})
+}
+
+// contextStream overrides the stream context.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context { return s.ctx }
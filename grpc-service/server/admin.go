@@ -0,0 +1,317 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/pb"
+)
+
+// documentStatuses are the statuses a filter may name.
+var documentStatuses = map[string]bool{
+	StatusPending: true, StatusProcessing: true, StatusCompleted: true,
+	StatusPartial: true, StatusFailed: true, StatusNeedsReview: true,
+}
+
+// documentFilter is a parsed pb.DocumentFilter.
+type documentFilter struct {
+	ids         map[string]bool
+	tenant      string
+	status      string
+	before      time.Time
+	unpublished bool
+}
+
+// parseFilter checks f; a nil filter matches every document.
+func parseFilter(f *pb.DocumentFilter, now time.Time) (*documentFilter, error) {
+	df := &documentFilter{}
+	if f == nil {
+		return df, nil
+	}
+	if len(f.DocumentIds) > 0 {
+		df.ids = make(map[string]bool, len(f.DocumentIds))
+		for _, id := range f.DocumentIds {
+			df.ids[id] = true
+		}
+	}
+	if f.Status != "" && !documentStatuses[f.Status] {
+		return nil, status.Errorf(codes.InvalidArgument, "filter.status: unknown status %q", f.Status)
+	}
+	if f.OlderThan != "" {
+		d, err := time.ParseDuration(f.OlderThan)
+		if err != nil || d <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "filter.older_than: %q, want a positive duration such as 72h", f.OlderThan)
+		}
+		df.before = now.Add(-d)
+	}
+	df.tenant, df.status, df.unpublished = f.TenantId, f.Status, f.Unpublished
+	return df, nil
+}
+
+// empty reports whether f would match every document.
+func (f *documentFilter) empty() bool {
+	return f.ids == nil && f.tenant == "" && f.status == "" && f.before.IsZero() && !f.unpublished
+}
+
+func (f *documentFilter) match(doc *Document) bool {
+	switch {
+	case f.ids != nil && !f.ids[doc.ID]:
+		return false
+	case f.tenant != "" && doc.Tenant != f.tenant:
+		return false
+	case f.status != "" && doc.Status != f.status:
+		return false
+	case !f.before.IsZero() && !doc.CreatedAt.Before(f.before):
+		return false
+	case f.unpublished && doc.PublishError == "":
+		return false
+	}
+	return true
+}
+
+// matching returns the documents f matches, ordered by ID. Callers must
+// hold s.mu.
+func (s *Server) matching(f *documentFilter) []*Document {
+	var docs []*Document
+	for _, doc := range s.docs {
+		if f.match(doc) {
+			docs = append(docs, doc)
+		}
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	return docs
+}
+
+// ---------------------------------------------------------------------------
+// gRPC service implementation
+// ---------------------------------------------------------------------------
+
+// GetDocumentCounts counts stored documents by status, in total and per
+// tenant. Unlike ListDocuments it sees every tenant; it is meant for
+// operators. Like every method here it is refused unless an access policy
+// grants it.
+func (s *Server) GetDocumentCounts(ctx context.Context, req *pb.GetDocumentCountsRequest) (*pb.GetDocumentCountsResponse, error) {
+	if err := s.authorizeCrossTenant(ctx, "GetDocumentCounts"); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resp := &pb.GetDocumentCountsResponse{ByStatus: map[string]int64{}, Tenants: []*pb.TenantCounts{}}
+	tenants := make(map[string]*pb.TenantCounts)
+	for _, doc := range s.docs {
+		if req.TenantId != "" && doc.Tenant != req.TenantId {
+			continue
+		}
+		tc, ok := tenants[doc.Tenant]
+		if !ok {
+			tc = &pb.TenantCounts{TenantId: doc.Tenant, ByStatus: map[string]int64{}}
+			tenants[doc.Tenant] = tc
+			resp.Tenants = append(resp.Tenants, tc)
+		}
+		tc.Total++
+		tc.ByStatus[doc.Status]++
+		resp.Total++
+		resp.ByStatus[doc.Status]++
+	}
+	sort.Slice(resp.Tenants, func(i, j int) bool { return resp.Tenants[i].TenantId < resp.Tenants[j].TenantId })
+	return resp, nil
+}
+
+// RequeueDocuments sends every document the filter matches through
+// extraction again, in any tenant. Unlike ReprocessDocument it is an
+// operator's recovery tool: admission control and quotas do not apply and
+// the work is not metered. An empty filter is refused rather than
+// requeueing everything.
+func (s *Server) RequeueDocuments(ctx context.Context, req *pb.RequeueDocumentsRequest) (*pb.RequeueDocumentsResponse, error) {
+	if err := s.authorizeCrossTenant(ctx, "RequeueDocuments"); err != nil {
+		return nil, err
+	}
+	f, err := parseFilter(req.Filter, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if f.empty() {
+		return nil, status.Error(codes.InvalidArgument, "filter must select documents; set document_ids, tenant_id, status, older_than or unpublished")
+	}
+	if !req.DryRun && s.producer == nil {
+		return nil, status.Error(codes.Unavailable, "kafka is unavailable; cannot requeue")
+	}
+
+	type job struct {
+		doc        *Document
+		pdfData    []byte
+		dataPoints []string
+	}
+	var jobs []job
+	resp := &pb.RequeueDocumentsResponse{DocumentIds: []string{}, Failed: []string{}}
+
+	s.mu.Lock()
+	actor := actorFrom(ctx)
+	for _, doc := range s.matching(f) {
+		resp.DocumentIds = append(resp.DocumentIds, doc.ID)
+		if req.DryRun {
+			continue
+		}
+		previous := doc.Status
+		queue(doc)
+		record(doc, EventRequeued, actor, map[string]string{"previous_status": previous})
+		jobs = append(jobs, job{doc, doc.PDFData, doc.DataPoints})
+	}
+	s.mu.Unlock()
+
+	for _, j := range jobs {
+		if err := s.publish(ctx, j.doc, j.pdfData, j.dataPoints); err != nil {
+			slog.WarnContext(ctx, "admin: requeue publish failed", "document_id", j.doc.ID, "tenant", j.doc.Tenant, "error", err)
+			resp.Failed = append(resp.Failed, j.doc.ID)
+			continue
+		}
+		resp.Requeued++
+	}
+	if !req.DryRun {
+		slog.InfoContext(ctx, "admin: documents requeued", "matched", len(resp.DocumentIds),
+			"requeued", resp.Requeued, "failed", len(resp.Failed))
+	}
+	return resp, nil
+}
+
+// PurgeDocuments deletes the documents the filter matches, in any tenant.
+// The filter must set older_than. Pending and processing documents are
+// kept unless the filter names their status.
+func (s *Server) PurgeDocuments(ctx context.Context, req *pb.PurgeDocumentsRequest) (*pb.PurgeDocumentsResponse, error) {
+	if err := s.authorizeCrossTenant(ctx, "PurgeDocuments"); err != nil {
+		return nil, err
+	}
+	if req.Filter == nil || req.Filter.OlderThan == "" {
+		return nil, status.Error(codes.InvalidArgument, "filter.older_than is required")
+	}
+	f, err := parseFilter(req.Filter, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resp := &pb.PurgeDocumentsResponse{DocumentIds: []string{}}
+	for _, doc := range s.matching(f) {
+		if f.status == "" && (doc.Status == StatusPending || doc.Status == StatusProcessing) {
+			continue
+		}
+		resp.DocumentIds = append(resp.DocumentIds, doc.ID)
+		if !req.DryRun {
+			delete(s.docs, doc.ID)
+			resp.Purged++
+		}
+	}
+	if !req.DryRun {
+		slog.InfoContext(ctx, "admin: documents purged", "purged", resp.Purged, "older_than", req.Filter.OlderThan)
+	}
+	return resp, nil
+}
+
+// ListOutbox lists stored documents whose upload event has not reached
+// Kafka, oldest failure first. They are retried by the sweeper, or at once
+// with RequeueDocuments and the unpublished filter.
+func (s *Server) ListOutbox(ctx context.Context, req *pb.ListOutboxRequest) (*pb.ListOutboxResponse, error) {
+	if err := s.authorizeCrossTenant(ctx, "ListOutbox"); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var docs []*Document
+	for _, doc := range s.docs {
+		if doc.PublishError == "" || (req.TenantId != "" && doc.Tenant != req.TenantId) {
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].PublishFailedAt.Before(docs[j].PublishFailedAt) })
+
+	resp := &pb.ListOutboxResponse{Entries: make([]*pb.OutboxEntry, 0, len(docs))}
+	for _, doc := range docs {
+		resp.Entries = append(resp.Entries, &pb.OutboxEntry{
+			DocumentId: doc.ID,
+			TenantId:   doc.Tenant,
+			Filename:   doc.Filename,
+			Status:     doc.Status,
+			Error:      doc.PublishError,
+			FailedAt:   doc.PublishFailedAt.UTC().Format(timelineTimeFormat),
+		})
+	}
+	return resp, nil
+}
+
+// ---------------------------------------------------------------------------
+// HTTP REST handlers
+// ---------------------------------------------------------------------------
+
+// GET /admin/documents/counts — documents by status, in total and per
+// tenant; optional query: tenant_id
+func (s *Server) handleGetDocumentCounts(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.GetDocumentCounts(r.Context(), &pb.GetDocumentCountsRequest{TenantId: r.URL.Query().Get("tenant_id")})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// POST /admin/documents/requeue — send matching documents through extraction again
+// Body: {"filter": {"document_ids": [...], "tenant_id": "...", "status": "failed", "older_than": "1h", "unpublished": true}, "dry_run": false}
+func (s *Server) handleRequeueDocuments(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Filter *pb.DocumentFilter `json:"filter"`
+		DryRun bool               `json:"dry_run"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	resp, err := s.RequeueDocuments(r.Context(), &pb.RequeueDocumentsRequest{Filter: body.Filter, DryRun: body.DryRun})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// POST /admin/documents/purge — delete matching documents
+// Body: {"filter": {"older_than": "720h", "tenant_id": "...", "status": "completed"}, "dry_run": false}
+func (s *Server) handlePurgeDocuments(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Filter *pb.DocumentFilter `json:"filter"`
+		DryRun bool               `json:"dry_run"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	resp, err := s.PurgeDocuments(r.Context(), &pb.PurgeDocumentsRequest{Filter: body.Filter, DryRun: body.DryRun})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GET /admin/outbox — documents whose upload event has not reached Kafka;
+// optional query: tenant_id
+func (s *Server) handleListOutbox(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.ListOutbox(r.Context(), &pb.ListOutboxRequest{TenantId: r.URL.Query().Get("tenant_id")})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
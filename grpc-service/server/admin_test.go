@@ -0,0 +1,164 @@
+package server
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/auth"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/pb"
+)
+
+func outboxIDs(t *testing.T, s *Server, tenant string) []string {
+	t.Helper()
+	resp, err := s.ListOutbox(as("root", "ops", auth.RoleAdmin), &pb.ListOutboxRequest{TenantId: tenant})
+	if err != nil {
+		t.Fatalf("ListOutbox: %v", err)
+	}
+	ids := []string{}
+	for _, e := range resp.Entries {
+		ids = append(ids, e.DocumentId)
+	}
+	return ids
+}
+
+// TestOutboxDrainsOnRequeue uploads while Kafka is down, then brings it back
+// and requeues the outbox.
+func TestOutboxDrainsOnRequeue(t *testing.T) {
+	s := NewServer(nil, Options{Policy: testPolicy()})
+	admin := as("root", "ops", auth.RoleAdmin)
+	first := upload(t, s, as("alice", "acme", auth.RoleUploader))
+	second := upload(t, s, as("bob", "globex", auth.RoleUploader))
+
+	if got, want := outboxIDs(t, s, ""), []string{first, second}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("outbox = %q, want %q, oldest first", got, want)
+	}
+	if got, want := outboxIDs(t, s, "globex"), []string{second}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("globex outbox = %q, want %q", got, want)
+	}
+
+	unpublished := &pb.DocumentFilter{Unpublished: true}
+	_, err := s.RequeueDocuments(admin, &pb.RequeueDocumentsRequest{Filter: unpublished})
+	wantCode(t, err, codes.Unavailable)
+
+	dry, err := s.RequeueDocuments(admin, &pb.RequeueDocumentsRequest{Filter: unpublished, DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	want := []string{first, second}
+	sort.Strings(want)
+	if !reflect.DeepEqual(dry.DocumentIds, want) || dry.Requeued != 0 {
+		t.Fatalf("dry run = %+v, want %q matched and none requeued", dry, want)
+	}
+	if n := countEvents(s.docs[first], EventRequeued); n != 0 {
+		t.Fatalf("dry run recorded %d requeued events", n)
+	}
+
+	s.producer = newTestProducer(t)
+	resp, err := s.RequeueDocuments(admin, &pb.RequeueDocumentsRequest{Filter: unpublished})
+	if err != nil {
+		t.Fatalf("RequeueDocuments: %v", err)
+	}
+	if resp.Requeued != 2 || len(resp.Failed) != 0 {
+		t.Errorf("requeue = %+v, want 2 requeued", resp)
+	}
+	if got := outboxIDs(t, s, ""); len(got) != 0 {
+		t.Errorf("outbox after requeue = %q, want it empty", got)
+	}
+	for _, id := range want {
+		if n := countEvents(s.docs[id], EventRequeued); n != 1 {
+			t.Errorf("%s: %d requeued events, want 1", id, n)
+		}
+	}
+}
+
+func TestAdminFilters(t *testing.T) {
+	s := NewServer(nil, Options{Policy: testPolicy()})
+	admin := as("root", "ops", auth.RoleAdmin)
+
+	tests := []struct {
+		name   string
+		filter *pb.DocumentFilter
+		purge  bool
+		want   codes.Code
+	}{
+		{"requeue without filter", nil, false, codes.InvalidArgument},
+		{"requeue with empty filter", &pb.DocumentFilter{}, false, codes.InvalidArgument},
+		{"requeue unknown status", &pb.DocumentFilter{Status: "stuck"}, false, codes.InvalidArgument},
+		{"requeue bad duration", &pb.DocumentFilter{OlderThan: "3 days"}, false, codes.InvalidArgument},
+		{"requeue negative duration", &pb.DocumentFilter{OlderThan: "-1h"}, false, codes.InvalidArgument},
+		{"requeue by tenant", &pb.DocumentFilter{TenantId: "acme"}, false, codes.OK},
+		{"purge without older_than", &pb.DocumentFilter{Status: StatusFailed}, true, codes.InvalidArgument},
+		{"purge bad duration", &pb.DocumentFilter{OlderThan: "0s"}, true, codes.InvalidArgument},
+		{"purge", &pb.DocumentFilter{OlderThan: "72h"}, true, codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var err error
+			if tt.purge {
+				_, err = s.PurgeDocuments(admin, &pb.PurgeDocumentsRequest{Filter: tt.filter, DryRun: true})
+			} else {
+				_, err = s.RequeueDocuments(admin, &pb.RequeueDocumentsRequest{Filter: tt.filter, DryRun: true})
+			}
+			if got := status.Code(err); got != tt.want {
+				t.Errorf("err = %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPurgeKeepsDocumentsInFlight(t *testing.T) {
+	s := NewServer(nil, Options{Policy: testPolicy()})
+	admin := as("root", "ops", auth.RoleAdmin)
+	alice := as("alice", "acme", auth.RoleUploader)
+	old, pending, recent := upload(t, s, alice), upload(t, s, alice), upload(t, s, alice)
+	week := time.Now().Add(-7 * 24 * time.Hour)
+	s.docs[old].CreatedAt, s.docs[old].Status = week, StatusCompleted
+	s.docs[pending].CreatedAt = week
+	s.docs[recent].Status = StatusCompleted
+
+	resp, err := s.PurgeDocuments(admin, &pb.PurgeDocumentsRequest{Filter: &pb.DocumentFilter{OlderThan: "72h"}})
+	if err != nil {
+		t.Fatalf("PurgeDocuments: %v", err)
+	}
+	if !reflect.DeepEqual(resp.DocumentIds, []string{old}) || resp.Purged != 1 {
+		t.Fatalf("purge = %+v, want only %s", resp, old)
+	}
+	if _, ok := s.docs[old]; ok {
+		t.Error("purged document still stored")
+	}
+
+	// Naming the status purges pending documents too.
+	resp, err = s.PurgeDocuments(admin, &pb.PurgeDocumentsRequest{Filter: &pb.DocumentFilter{OlderThan: "72h", Status: StatusPending}})
+	if err != nil || resp.Purged != 1 {
+		t.Fatalf("purge pending = %+v, %v; want 1 purged", resp, err)
+	}
+	if len(s.docs) != 1 {
+		t.Errorf("%d documents left, want the recent one", len(s.docs))
+	}
+}
+
+func TestDeadLettersWithoutTopic(t *testing.T) {
+	admin := as("root", "ops", auth.RoleAdmin)
+	byReason := &pb.ReplayDeadLettersRequest{Reason: "nlp"}
+
+	s := NewServer(nil, Options{Policy: testPolicy()})
+	_, err := s.ListDeadLetters(admin, &pb.ListDeadLettersRequest{})
+	wantCode(t, err, codes.Unavailable)
+	_, err = s.ReplayDeadLetters(admin, &pb.ReplayDeadLettersRequest{DryRun: true})
+	wantCode(t, err, codes.InvalidArgument)
+	_, err = s.ReplayDeadLetters(admin, byReason)
+	wantCode(t, err, codes.Unavailable)
+
+	// With a producer but no dead-letter reader, even a dry run cannot
+	// see what it would replay.
+	s = NewServer(newTestProducer(t), Options{Policy: testPolicy()})
+	_, err = s.ReplayDeadLetters(admin, &pb.ReplayDeadLettersRequest{Reason: "nlp", DryRun: true})
+	wantCode(t, err, codes.Unavailable)
+	_, err = s.ReplayDeadLetters(as("alice", "acme", auth.RoleUploader), byReason)
+	wantCode(t, err, codes.PermissionDenied)
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// LagSource reports how far the extraction consumers are behind.
+type LagSource interface {
+	Lag() (int64, error)
+}
+
+// AdmissionPolicy rejects new work while the pipeline is backed up. Zero
+// limits are disabled.
+type AdmissionPolicy struct {
+	// MaxPending is the most documents that may be pending at once.
+	MaxPending int
+	// MaxLag is the most unconsumed upload events tolerated on Kafka,
+	// measured by Lag.
+	MaxLag int64
+	Lag    LagSource
+	// RetryAfter is suggested to rejected callers.
+	RetryAfter time.Duration
+}
+
+// overloadedError is a ResourceExhausted status that also carries a retry
+// hint for the REST layer.
+type overloadedError struct {
+	msg        string
+	retryAfter time.Duration
+}
+
+func (e *overloadedError) Error() string { return e.msg }
+
+func (e *overloadedError) GRPCStatus() *status.Status {
+	return status.New(codes.ResourceExhausted, e.msg)
+}
+
+// setRetryAfter adds a Retry-After header when err carries a retry hint.
+func setRetryAfter(w http.ResponseWriter, err error) {
+	var oe *overloadedError
+	if errors.As(err, &oe) && oe.retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(oe.retryAfter.Seconds()))))
+	}
+}
+
+// admit decides whether another document may enter the pipeline. Callers
+// must not hold s.mu. A lag measurement failure admits the document rather
+// than blocking uploads on monitoring.
+func (s *Server) admit(ctx context.Context) error {
+	p := s.admission
+	if p.MaxPending > 0 {
+		s.mu.RLock()
+		pending := 0
+		for _, doc := range s.docs {
+			if doc.Status == StatusPending || doc.Status == StatusProcessing {
+				pending++
+			}
+		}
+		s.mu.RUnlock()
+		if pending >= p.MaxPending {
+			return s.overloaded(ctx, fmt.Sprintf("too many documents pending (%d); try again later", pending))
+		}
+	}
+	if p.MaxLag > 0 && p.Lag != nil {
+		lag, err := p.Lag.Lag()
+		if err != nil {
+			slog.WarnContext(ctx, "admission: consumer lag unknown, admitting", "error", err)
+		} else if lag > p.MaxLag {
+			return s.overloaded(ctx, fmt.Sprintf("extraction backlog too large (%d events); try again later", lag))
+		}
+	}
+	return nil
+}
+
+func (s *Server) overloaded(ctx context.Context, msg string) error {
+	err := &overloadedError{msg: msg, retryAfter: s.admission.RetryAfter}
+	if err.retryAfter > 0 {
+		secs := strconv.Itoa(int(math.Ceil(err.retryAfter.Seconds())))
+		_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", secs))
+	}
+	return err
+}
@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/kafka"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/metrics"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/pb"
+)
+
+// deadLetterReadTimeout bounds reading the whole dead-letter topic.
+const deadLetterReadTimeout = 30 * time.Second
+
+// deadLetterKey locates a message on the dead-letter topic.
+type deadLetterKey struct {
+	partition int32
+	offset    int64
+}
+
+// readDeadLetters returns every retained dead letter.
+func (s *Server) readDeadLetters(ctx context.Context) ([]*kafka.DeadLetter, error) {
+	if s.deadLetters == nil {
+		return nil, status.Error(codes.Unavailable, "the dead-letter topic is not available")
+	}
+	ctx, cancel := context.WithTimeout(ctx, deadLetterReadTimeout)
+	defer cancel()
+	letters, err := s.deadLetters.Read(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "read dead letters: %v", err)
+	}
+	return letters, nil
+}
+
+func deadLetterToPB(dl *kafka.DeadLetter, replayedAt time.Time) *pb.DeadLetterEntry {
+	e := &pb.DeadLetterEntry{
+		Partition:       dl.Partition,
+		Offset:          dl.Offset,
+		Reason:          dl.Reason,
+		Error:           dl.Error,
+		SourceTopic:     dl.SourceTopic,
+		SourcePartition: dl.SourcePartition,
+		SourceOffset:    dl.SourceOffset,
+		WorkerId:        dl.WorkerID,
+		DocumentId:      dl.DocumentID,
+		TenantId:        dl.TenantID,
+	}
+	if !dl.FailedAt.IsZero() {
+		e.FailedAt = dl.FailedAt.UTC().Format(timelineTimeFormat)
+	}
+	if !replayedAt.IsZero() {
+		e.ReplayedAt = replayedAt.UTC().Format(timelineTimeFormat)
+	}
+	return e
+}
+
+// ---------------------------------------------------------------------------
+// gRPC service implementation
+// ---------------------------------------------------------------------------
+
+// ListDeadLetters lists the upload events the consumer dead-lettered, in
+// any tenant, optionally only those of one tenant or reason. Entries
+// replayed since this service started carry replayed_at.
+func (s *Server) ListDeadLetters(ctx context.Context, req *pb.ListDeadLettersRequest) (*pb.ListDeadLettersResponse, error) {
+	if err := s.authorizeCrossTenant(ctx, "ListDeadLetters"); err != nil {
+		return nil, err
+	}
+	letters, err := s.readDeadLetters(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	resp := &pb.ListDeadLettersResponse{Topic: s.deadLetters.Topic(), Entries: []*pb.DeadLetterEntry{}}
+	for _, dl := range letters {
+		if (req.TenantId != "" && dl.TenantID != req.TenantId) || (req.Reason != "" && dl.Reason != req.Reason) {
+			continue
+		}
+		resp.Entries = append(resp.Entries, deadLetterToPB(dl, s.replayed[deadLetterKey{dl.Partition, dl.Offset}]))
+	}
+	return resp, nil
+}
+
+// ReplayDeadLetters publishes the selected dead letters to the uploads
+// topic again, as the consumer first received them. Entries already
+// replayed are skipped unless include_replayed is set. A stored document
+// that is replayed is reset the way a requeue resets it. Like
+// RequeueDocuments, admission control and quotas do not apply, and an
+// empty selection is refused.
+func (s *Server) ReplayDeadLetters(ctx context.Context, req *pb.ReplayDeadLettersRequest) (*pb.ReplayDeadLettersResponse, error) {
+	if err := s.authorizeCrossTenant(ctx, "ReplayDeadLetters"); err != nil {
+		return nil, err
+	}
+	if len(req.Entries) == 0 && req.TenantId == "" && req.Reason == "" {
+		return nil, status.Error(codes.InvalidArgument, "select dead letters; set entries, tenant_id or reason")
+	}
+	if !req.DryRun && s.producer == nil {
+		return nil, status.Error(codes.Unavailable, "kafka is unavailable; cannot replay")
+	}
+	letters, err := s.readDeadLetters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var refs map[deadLetterKey]bool
+	if len(req.Entries) > 0 {
+		refs = make(map[deadLetterKey]bool, len(req.Entries))
+		for _, e := range req.Entries {
+			refs[deadLetterKey{e.Partition, e.Offset}] = true
+		}
+	}
+
+	type job struct {
+		dl  *kafka.DeadLetter
+		doc *Document // nil when the document is not stored
+	}
+	var jobs []job
+	resp := &pb.ReplayDeadLettersResponse{Entries: []*pb.DeadLetterRef{}, Failed: []*pb.DeadLetterRef{}}
+
+	s.mu.Lock()
+	actor := actorFrom(ctx)
+	for _, dl := range letters {
+		key := deadLetterKey{dl.Partition, dl.Offset}
+		switch {
+		case refs != nil && !refs[key],
+			req.TenantId != "" && dl.TenantID != req.TenantId,
+			req.Reason != "" && dl.Reason != req.Reason,
+			!req.IncludeReplayed && !s.replayed[key].IsZero():
+			continue
+		}
+		resp.Entries = append(resp.Entries, &pb.DeadLetterRef{Partition: dl.Partition, Offset: dl.Offset})
+		if req.DryRun {
+			continue
+		}
+		j := job{dl: dl}
+		if doc, ok := s.docs[dl.DocumentID]; ok && doc.Tenant == dl.TenantID {
+			previous := doc.Status
+			queue(doc)
+			record(doc, EventReplayed, actor, map[string]string{
+				"previous_status":       previous,
+				"reason":                dl.Reason,
+				"dead_letter_partition": strconv.Itoa(int(dl.Partition)),
+				"dead_letter_offset":    strconv.FormatInt(dl.Offset, 10),
+			})
+			j.doc = doc
+		}
+		jobs = append(jobs, j)
+	}
+	s.mu.Unlock()
+
+	for _, j := range jobs {
+		ref := &pb.DeadLetterRef{Partition: j.dl.Partition, Offset: j.dl.Offset}
+		partition, offset, err := s.producer.Replay(ctx, j.dl)
+		if j.doc != nil {
+			s.recordPublish(j.doc, partition, offset, err)
+		}
+		if err != nil {
+			metrics.KafkaPublishErrors.Inc()
+			slog.WarnContext(ctx, "admin: dead-letter replay failed", "partition", ref.Partition, "offset", ref.Offset,
+				"document_id", j.dl.DocumentID, "error", err)
+			resp.Failed = append(resp.Failed, ref)
+			continue
+		}
+		metrics.KafkaPublished.Inc()
+		s.mu.Lock()
+		s.replayed[deadLetterKey{ref.Partition, ref.Offset}] = time.Now()
+		s.mu.Unlock()
+		resp.Replayed++
+	}
+	if !req.DryRun {
+		slog.InfoContext(ctx, "admin: dead letters replayed", "matched", len(resp.Entries),
+			"replayed", resp.Replayed, "failed", len(resp.Failed))
+	}
+	return resp, nil
+}
+
+// ---------------------------------------------------------------------------
+// HTTP REST handlers
+// ---------------------------------------------------------------------------
+
+// GET /admin/dead-letters — upload events the consumer dead-lettered;
+// optional query: tenant_id, reason
+func (s *Server) handleListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	resp, err := s.ListDeadLetters(r.Context(), &pb.ListDeadLettersRequest{TenantId: q.Get("tenant_id"), Reason: q.Get("reason")})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// POST /admin/dead-letters/replay — publish dead letters to the uploads topic again
+// Body: {"entries": [{"partition": 0, "offset": 12}], "tenant_id": "...", "reason": "nlp", "include_replayed": false, "dry_run": false}
+func (s *Server) handleReplayDeadLetters(w http.ResponseWriter, r *http.Request) {
+	var req pb.ReplayDeadLettersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	resp, err := s.ReplayDeadLetters(r.Context(), &req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+	"errors"
+)
+
+// checkKafka reports whether uploads can be published. A nil producer
+// means Kafka was unreachable at startup, so nothing will ever be
+// published until the service restarts.
+func (s *Server) checkKafka(ctx context.Context) error {
+	if s.producer == nil {
+		return errors.New("producer not connected: Kafka was unavailable at startup")
+	}
+	return s.producer.Ping(ctx)
+}
+
+// checkStore reports whether the document store can be read, failing when
+// a writer has held its lock for the whole check.
+func (s *Server) checkStore(ctx context.Context) error {
+	acquired := make(chan struct{})
+	go func() {
+		s.mu.RLock()
+		s.mu.RUnlock()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		return nil
+	case <-ctx.Done():
+		return errors.New("document store lock not acquired: " + ctx.Err().Error())
+	}
+}
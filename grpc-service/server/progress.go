@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/auth"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/pb"
+)
+
+// Processing stages reported by the consumer.
+const (
+	StagePickedUp   = "picked_up"
+	StageExtracting = "extracting"
+)
+
+// Limits on what a progress report may store.
+const (
+	maxStageLen   = 64
+	maxMessageLen = 500
+)
+
+// Processing describes the consumer working on a document.
+type Processing struct {
+	Worker    string
+	StartedAt time.Time
+	UpdatedAt time.Time
+	Stage     string
+	Message   string
+}
+
+// processingInfo converts p for the API; nil stays nil.
+func processingInfo(p *Processing) *pb.ProcessingInfo {
+	if p == nil {
+		return nil
+	}
+	return &pb.ProcessingInfo{
+		WorkerId:  p.Worker,
+		StartedAt: p.StartedAt.Format(timelineTimeFormat),
+		UpdatedAt: p.UpdatedAt.Format(timelineTimeFormat),
+		Stage:     p.Stage,
+		Message:   p.Message,
+	}
+}
+
+// callbackDoc finds the document a consumer callback is about. The tenant,
+// echoed from the Kafka event, must match the document's. Callers must
+// hold s.mu.
+func (s *Server) callbackDoc(id, tenant string) (*Document, error) {
+	if tenant == "" {
+		tenant = auth.DefaultTenant
+	}
+	doc, ok := s.docs[id]
+	if !ok || doc.Tenant != tenant {
+		return nil, status.Errorf(codes.NotFound, "document %s not found", id)
+	}
+	return doc, nil
+}
+
+// ---------------------------------------------------------------------------
+// gRPC service implementation
+// ---------------------------------------------------------------------------
+
+// ReportProgress marks a document as processing by the reporting worker.
+// Like UpdateDataPoints it only accepts the signed consumer callback. A
+// report for a document whose results are already stored is refused so a
+// late report cannot hide the outcome; a report from another worker, such
+// as after a rebalance, takes over.
+func (s *Server) ReportProgress(ctx context.Context, req *pb.ReportProgressRequest) (*pb.ReportProgressResponse, error) {
+	if err := s.authorize(ctx, "ReportProgress"); err != nil {
+		return nil, err
+	}
+	if !auth.IsServiceCall(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "progress may only be posted by the signed consumer callback")
+	}
+	if req.Stage == "" || len(req.Stage) > maxStageLen {
+		return nil, status.Errorf(codes.InvalidArgument, "stage must be 1 to %d characters", maxStageLen)
+	}
+	message := req.Message
+	if len(message) > maxMessageLen {
+		message = message[:maxMessageLen]
+	}
+	worker := req.WorkerId
+	if worker == "" {
+		worker = "consumer"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, err := s.callbackDoc(req.DocumentId, req.TenantId)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Status != StatusPending && doc.Status != StatusProcessing {
+		return nil, status.Errorf(codes.FailedPrecondition, "document %s is already %s", doc.ID, doc.Status)
+	}
+
+	now := time.Now().UTC()
+	started, err := time.Parse(time.RFC3339Nano, req.StartedAt)
+	if err != nil {
+		started = now
+	}
+	p := doc.Processing
+	if p == nil || p.Worker != worker || !p.StartedAt.Equal(started.UTC()) {
+		p = &Processing{Worker: worker, StartedAt: started.UTC()}
+		doc.Processing = p
+	}
+	p.Stage, p.Message, p.UpdatedAt = req.Stage, message, now
+	doc.Status = StatusProcessing
+	recordConsumerEvents(doc, worker, req.Events)
+
+	slog.DebugContext(ctx, "progress reported", "document_id", doc.ID, "tenant", doc.Tenant,
+		"worker", worker, "stage", p.Stage)
+	return &pb.ReportProgressResponse{Status: doc.Status}, nil
+}
+
+// ---------------------------------------------------------------------------
+// HTTP REST handlers
+// ---------------------------------------------------------------------------
+
+// POST /documents/{id}/progress — called by the NLP consumer while it works
+// on a document; requests must be HMAC-signed (see auth.Sign)
+// Body: {"tenant_id": "...", "worker_id": "...", "started_at": "...", "stage": "extracting", "message": "...", "events": [...]}
+func (s *Server) handleReportProgress(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		TenantID  string              `json:"tenant_id"`
+		WorkerID  string              `json:"worker_id"`
+		StartedAt string              `json:"started_at"`
+		Stage     string              `json:"stage"`
+		Message   string              `json:"message"`
+		Events    []*pb.TimelineEvent `json:"events"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := s.ReportProgress(r.Context(), &pb.ReportProgressRequest{
+		DocumentId: r.PathValue("id"),
+		TenantId:   body.TenantID,
+		WorkerId:   body.WorkerID,
+		StartedAt:  body.StartedAt,
+		Stage:      body.Stage,
+		Message:    body.Message,
+		Events:     body.Events,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
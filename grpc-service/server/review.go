@@ -0,0 +1,383 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/auth"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/pb"
+)
+
+// Revision sources.
+const (
+	RevisionSourceExtractor = "extractor"
+	RevisionSourceReview    = "review"
+)
+
+// Revision is one immutable snapshot of a document's results.
+type Revision struct {
+	Number    int
+	Source    string
+	Author    string
+	CreatedAt time.Time
+	Results   map[string]string
+	Details   map[string]*pb.DataPointResult
+}
+
+// ReviewPolicy decides which extraction results need a human to look at them.
+// Documents that fail validation rules are always sent to review as well.
+// A zero threshold disables the confidence check for that data point.
+type ReviewPolicy struct {
+	// MinConfidence applies to every data point without its own threshold.
+	MinConfidence float64
+	// MinConfidenceByDataPoint overrides MinConfidence per data point name.
+	MinConfidenceByDataPoint map[string]float64
+}
+
+func (p ReviewPolicy) threshold(dataPoint string) float64 {
+	if t, ok := p.MinConfidenceByDataPoint[dataPoint]; ok {
+		return t
+	}
+	return p.MinConfidence
+}
+
+// reasons lists every found value whose confidence is below its threshold.
+// Values the extractor did not produce are already reflected in the
+// document status and are not repeated here.
+func (p ReviewPolicy) reasons(details map[string]*pb.DataPointResult) []string {
+	var out []string
+	for dp, d := range details {
+		if d.Status != DataPointFound && d.Status != DataPointAmbiguous {
+			continue
+		}
+		if t := p.threshold(dp); d.Confidence < t {
+			out = append(out, fmt.Sprintf("%s: confidence %.2f below %.2f", dp, d.Confidence, t))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// copyResults returns copies of doc's current results to build the next
+// revision from.
+func copyResults(doc *Document) (map[string]string, map[string]*pb.DataPointResult) {
+	results := make(map[string]string, len(doc.Results))
+	for k, v := range doc.Results {
+		results[k] = v
+	}
+	details := make(map[string]*pb.DataPointResult, len(doc.Details))
+	for k, v := range doc.Details {
+		details[k] = cloneDataPointResult(v)
+	}
+	return results, details
+}
+
+// addRevision records results and details as the document's newest revision
+// and makes them current. Callers must hold s.mu for writing.
+func addRevision(doc *Document, source, author string, results map[string]string, details map[string]*pb.DataPointResult) *Revision {
+	rev := &Revision{
+		Number:    len(doc.Revisions) + 1,
+		Source:    source,
+		Author:    author,
+		CreatedAt: time.Now().UTC(),
+		Results:   results,
+		Details:   details,
+	}
+	doc.Revisions = append(doc.Revisions, rev)
+	doc.Results, doc.Details = copyResults(&Document{Results: results, Details: details})
+	return rev
+}
+
+func reviewItem(doc *Document) *pb.ReviewItem {
+	item := &pb.ReviewItem{
+		DocumentId:    doc.ID,
+		Filename:      doc.Filename,
+		Revision:      int32(len(doc.Revisions)),
+		ReviewReasons: append([]string(nil), doc.ReviewReasons...),
+		ClaimedBy:     doc.ClaimedBy,
+	}
+	if !doc.ClaimedAt.IsZero() {
+		item.ClaimedAt = doc.ClaimedAt.Format(time.RFC3339)
+	}
+	return item
+}
+
+// reviewerFrom returns the reviewer to record for a request: the
+// authenticated caller when there is one, otherwise the name the client sent.
+func reviewerFrom(ctx context.Context, requested string) string {
+	if id, ok := auth.FromContext(ctx); ok {
+		return id.Subject
+	}
+	return requested
+}
+
+// reviewableDoc looks up a document that is waiting for review on behalf of
+// reviewer. Callers must hold s.mu.
+func (s *Server) reviewableDoc(ctx context.Context, id, reviewer string) (*Document, error) {
+	if reviewer == "" {
+		return nil, status.Error(codes.InvalidArgument, "reviewer is required")
+	}
+	doc, err := s.lookup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Status != StatusNeedsReview {
+		return nil, status.Errorf(codes.FailedPrecondition, "document %s is %s, not %s", id, doc.Status, StatusNeedsReview)
+	}
+	return doc, nil
+}
+
+// ---------------------------------------------------------------------------
+// gRPC service implementation
+// ---------------------------------------------------------------------------
+
+func (s *Server) ListReviewQueue(ctx context.Context, _ *pb.ListReviewQueueRequest) (*pb.ListReviewQueueResponse, error) {
+	if err := s.authorize(ctx, "ListReviewQueue"); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tenant := auth.TenantFromContext(ctx)
+	items := make([]*pb.ReviewItem, 0)
+	for _, doc := range s.docs {
+		if doc.Tenant == tenant && doc.Status == StatusNeedsReview {
+			items = append(items, reviewItem(doc))
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].DocumentId < items[j].DocumentId })
+	return &pb.ListReviewQueueResponse{Items: items}, nil
+}
+
+func (s *Server) ClaimReview(ctx context.Context, req *pb.ClaimReviewRequest) (*pb.ClaimReviewResponse, error) {
+	if err := s.authorize(ctx, "ClaimReview"); err != nil {
+		return nil, err
+	}
+
+	reviewer := reviewerFrom(ctx, req.Reviewer)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, err := s.reviewableDoc(ctx, req.DocumentId, reviewer)
+	if err != nil {
+		return nil, err
+	}
+	if doc.ClaimedBy != "" && doc.ClaimedBy != reviewer {
+		return nil, status.Errorf(codes.FailedPrecondition, "document %s is already claimed by %s", doc.ID, doc.ClaimedBy)
+	}
+	if doc.ClaimedBy == "" {
+		doc.ClaimedBy, doc.ClaimedAt = reviewer, time.Now().UTC()
+		record(doc, EventReviewClaimed, reviewer, nil)
+	}
+	return &pb.ClaimReviewResponse{Item: reviewItem(doc)}, nil
+}
+
+func (s *Server) ApproveReview(ctx context.Context, req *pb.ApproveReviewRequest) (*pb.ApproveReviewResponse, error) {
+	if err := s.authorize(ctx, "ApproveReview"); err != nil {
+		return nil, err
+	}
+
+	reviewer := reviewerFrom(ctx, req.Reviewer)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, err := s.reviewableDoc(ctx, req.DocumentId, reviewer)
+	if err != nil {
+		return nil, err
+	}
+	if doc.ClaimedBy != reviewer {
+		return nil, status.Errorf(codes.FailedPrecondition, "document %s must be claimed by %s before approval", doc.ID, reviewer)
+	}
+
+	if len(req.Corrections) > 0 {
+		results, details := copyResults(doc)
+		for dp, value := range req.Corrections {
+			if _, ok := results[dp]; !ok {
+				return nil, status.Errorf(codes.InvalidArgument, "document %s has no data point %q", doc.ID, dp)
+			}
+			results[dp] = value
+			details[dp] = correctedResult(details[dp], value, reviewer)
+		}
+		addRevision(doc, RevisionSourceReview, reviewer, results, details)
+		s.validate(doc)
+	}
+
+	doc.Status = documentStatus(doc.Details)
+	doc.ReviewReasons = nil
+	doc.ClaimedBy, doc.ClaimedAt = "", time.Time{}
+	record(doc, EventReviewed, reviewer, map[string]string{
+		"status":      doc.Status,
+		"revision":    strconv.Itoa(len(doc.Revisions)),
+		"corrections": strconv.Itoa(len(req.Corrections)),
+	})
+	return &pb.ApproveReviewResponse{Status: doc.Status, Revision: int32(len(doc.Revisions))}, nil
+}
+
+// correctedResult builds the result for a value entered by a reviewer. If the
+// value matches one of the extractor's candidates its location is kept so the
+// evidence stays linked.
+func correctedResult(prev *pb.DataPointResult, value, reviewer string) *pb.DataPointResult {
+	r := &pb.DataPointResult{
+		Value:      value,
+		Confidence: 1,
+		Status:     DataPointFound,
+		Reason:     "corrected by " + reviewer,
+	}
+	if prev == nil {
+		return r
+	}
+	r.Candidates = prev.Candidates
+	for _, c := range prev.Candidates {
+		if c.Value == value {
+			r.Page, r.Bbox, r.StartOffset, r.EndOffset, r.Snippet = c.Page, c.Bbox, c.StartOffset, c.EndOffset, c.Snippet
+			break
+		}
+	}
+	return r
+}
+
+func (s *Server) ReleaseReview(ctx context.Context, req *pb.ReleaseReviewRequest) (*pb.ReleaseReviewResponse, error) {
+	if err := s.authorize(ctx, "ReleaseReview"); err != nil {
+		return nil, err
+	}
+
+	reviewer := reviewerFrom(ctx, req.Reviewer)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	doc, err := s.reviewableDoc(ctx, req.DocumentId, reviewer)
+	if err != nil {
+		return nil, err
+	}
+	if doc.ClaimedBy != reviewer {
+		return nil, status.Errorf(codes.FailedPrecondition, "document %s is not claimed by %s", doc.ID, reviewer)
+	}
+	doc.ClaimedBy, doc.ClaimedAt = "", time.Time{}
+	record(doc, EventReviewReleased, reviewer, nil)
+	return &pb.ReleaseReviewResponse{Status: "released"}, nil
+}
+
+func (s *Server) ListRevisions(ctx context.Context, req *pb.ListRevisionsRequest) (*pb.ListRevisionsResponse, error) {
+	if err := s.authorize(ctx, "ListRevisions"); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	doc, err := s.lookup(ctx, req.DocumentId)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions := make([]*pb.ResultRevision, 0, len(doc.Revisions))
+	for _, rev := range doc.Revisions {
+		results, details := copyResults(&Document{Results: rev.Results, Details: rev.Details})
+		revisions = append(revisions, &pb.ResultRevision{
+			Revision:  int32(rev.Number),
+			Source:    rev.Source,
+			Author:    rev.Author,
+			CreatedAt: rev.CreatedAt.Format(time.RFC3339),
+			Results:   results,
+			Details:   details,
+		})
+	}
+	return &pb.ListRevisionsResponse{Revisions: revisions}, nil
+}
+
+// ---------------------------------------------------------------------------
+// HTTP REST handlers
+// ---------------------------------------------------------------------------
+
+// reviewBody is the JSON body accepted by the review endpoints.
+type reviewBody struct {
+	Reviewer    string            `json:"reviewer"`
+	Corrections map[string]string `json:"corrections"`
+}
+
+func decodeReviewBody(w http.ResponseWriter, r *http.Request) (reviewBody, bool) {
+	var body reviewBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return body, false
+	}
+	return body, true
+}
+
+// GET /review/queue — documents waiting for human review
+func (s *Server) handleListReviewQueue(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.ListReviewQueue(r.Context(), &pb.ListReviewQueueRequest{})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// POST /review/{id}/claim — Body: {"reviewer": "alice"}
+func (s *Server) handleClaimReview(w http.ResponseWriter, r *http.Request) {
+	body, ok := decodeReviewBody(w, r)
+	if !ok {
+		return
+	}
+	resp, err := s.ClaimReview(r.Context(), &pb.ClaimReviewRequest{DocumentId: r.PathValue("id"), Reviewer: body.Reviewer})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// POST /review/{id}/approve — Body: {"reviewer": "alice", "corrections": {"key": "value"}}
+func (s *Server) handleApproveReview(w http.ResponseWriter, r *http.Request) {
+	body, ok := decodeReviewBody(w, r)
+	if !ok {
+		return
+	}
+	resp, err := s.ApproveReview(r.Context(), &pb.ApproveReviewRequest{
+		DocumentId:  r.PathValue("id"),
+		Reviewer:    body.Reviewer,
+		Corrections: body.Corrections,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// POST /review/{id}/release — Body: {"reviewer": "alice"}
+func (s *Server) handleReleaseReview(w http.ResponseWriter, r *http.Request) {
+	body, ok := decodeReviewBody(w, r)
+	if !ok {
+		return
+	}
+	resp, err := s.ReleaseReview(r.Context(), &pb.ReleaseReviewRequest{DocumentId: r.PathValue("id"), Reviewer: body.Reviewer})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GET /documents/{id}/revisions — every stored version of a document's results
+func (s *Server) handleListRevisions(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.ListRevisions(r.Context(), &pb.ListRevisionsRequest{DocumentId: r.PathValue("id")})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
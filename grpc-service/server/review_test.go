@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/auth"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/pb"
+)
+
+func TestReviewReasons(t *testing.T) {
+	p := ReviewPolicy{MinConfidence: 0.8, MinConfidenceByDataPoint: map[string]float64{"iban": 0.95}}
+	details := map[string]*pb.DataPointResult{
+		"total":   {Status: DataPointFound, Confidence: 0.5},
+		"iban":    {Status: DataPointFound, Confidence: 0.9},
+		"date":    {Status: DataPointFound, Confidence: 0.9},
+		"vendor":  {Status: DataPointAmbiguous, Confidence: 0.6},
+		"missing": {Status: DataPointNotFound},
+	}
+	want := []string{
+		"iban: confidence 0.90 below 0.95",
+		"total: confidence 0.50 below 0.80",
+		"vendor: confidence 0.60 below 0.80",
+	}
+	if got := p.reasons(details); !reflect.DeepEqual(got, want) {
+		t.Errorf("reasons = %q, want %q", got, want)
+	}
+	if got := (ReviewPolicy{}).reasons(details); got != nil {
+		t.Errorf("zero policy reasons = %q, want none", got)
+	}
+}
+
+// TestReviewFlow walks one low-confidence document from the consumer's
+// callback through claim, release, claim and approval.
+func TestReviewFlow(t *testing.T) {
+	s := NewServer(nil, Options{Policy: testPolicy(), Review: ReviewPolicy{MinConfidence: 0.8}})
+	id := upload(t, s, as("alice", "acme", auth.RoleUploader), "total", "date")
+	carol := as("carol", "acme", auth.RoleReviewer)
+	dave := as("dave", "acme", auth.RoleReviewer)
+
+	// Claiming before results arrive is refused: nothing to review yet.
+	_, err := s.ClaimReview(carol, &pb.ClaimReviewRequest{DocumentId: id})
+	wantCode(t, err, codes.FailedPrecondition)
+
+	_, err = s.UpdateDataPoints(consumerCall(), &pb.UpdateDataPointsRequest{
+		DocumentId: id,
+		TenantId:   "acme",
+		Results:    map[string]string{"total": "1,200.00", "date": "2024-03-01"},
+		Details: map[string]*pb.DataPointResult{
+			"total": {Value: "1,200.00", Status: DataPointFound, Confidence: 0.42,
+				Candidates: []*pb.Candidate{{Value: "1,200.00", Page: 1}, {Value: "1,250.00", Page: 2}}},
+			"date": {Value: "2024-03-01", Status: DataPointFound, Confidence: 0.97},
+		},
+	})
+	if err != nil {
+		t.Fatalf("UpdateDataPoints: %v", err)
+	}
+	if doc := s.docs[id]; doc.Status != StatusNeedsReview || len(doc.ReviewReasons) != 1 {
+		t.Fatalf("after results: status %s, reasons %q; want needs_review for total only", doc.Status, doc.ReviewReasons)
+	}
+	queue, err := s.ListReviewQueue(carol, &pb.ListReviewQueueRequest{})
+	if err != nil || len(queue.Items) != 1 || queue.Items[0].DocumentId != id {
+		t.Fatalf("queue = %+v, %v; want the document", queue, err)
+	}
+
+	if _, err := s.ClaimReview(carol, &pb.ClaimReviewRequest{DocumentId: id}); err != nil {
+		t.Fatalf("carol claims: %v", err)
+	}
+	// Claiming again is idempotent for the holder, refused for anyone else,
+	// and the name a client sends cannot override its identity.
+	if _, err := s.ClaimReview(carol, &pb.ClaimReviewRequest{DocumentId: id}); err != nil {
+		t.Fatalf("carol claims again: %v", err)
+	}
+	_, err = s.ClaimReview(dave, &pb.ClaimReviewRequest{DocumentId: id, Reviewer: "carol"})
+	wantCode(t, err, codes.FailedPrecondition)
+	_, err = s.ApproveReview(dave, &pb.ApproveReviewRequest{DocumentId: id})
+	wantCode(t, err, codes.FailedPrecondition)
+	_, err = s.ReleaseReview(dave, &pb.ReleaseReviewRequest{DocumentId: id})
+	wantCode(t, err, codes.FailedPrecondition)
+
+	if _, err := s.ReleaseReview(carol, &pb.ReleaseReviewRequest{DocumentId: id}); err != nil {
+		t.Fatalf("carol releases: %v", err)
+	}
+	if _, err := s.ClaimReview(dave, &pb.ClaimReviewRequest{DocumentId: id}); err != nil {
+		t.Fatalf("dave claims after release: %v", err)
+	}
+
+	_, err = s.ApproveReview(dave, &pb.ApproveReviewRequest{DocumentId: id, Corrections: map[string]string{"vat": "0"}})
+	wantCode(t, err, codes.InvalidArgument)
+
+	resp, err := s.ApproveReview(dave, &pb.ApproveReviewRequest{DocumentId: id, Corrections: map[string]string{"total": "1,250.00"}})
+	if err != nil {
+		t.Fatalf("dave approves: %v", err)
+	}
+	if resp.Status != StatusCompleted || resp.Revision != 2 {
+		t.Errorf("approve = %+v, want completed at revision 2", resp)
+	}
+
+	doc := s.docs[id]
+	if doc.ClaimedBy != "" || doc.ReviewReasons != nil {
+		t.Errorf("after approval: claimed by %q, reasons %q", doc.ClaimedBy, doc.ReviewReasons)
+	}
+	rev := doc.Revisions[1]
+	if rev.Source != RevisionSourceReview || rev.Author != "dave" {
+		t.Errorf("revision 2 by %s/%s, want review/dave", rev.Source, rev.Author)
+	}
+	total := doc.Details["total"]
+	if total.Value != "1,250.00" || total.Confidence != 1 || total.Page != 2 {
+		t.Errorf("corrected total = %+v, want the page 2 candidate at confidence 1", total)
+	}
+	if first := doc.Revisions[0].Results["total"]; first != "1,200.00" {
+		t.Errorf("revision 1 total = %q, want it kept", first)
+	}
+
+	var types []string
+	for _, e := range doc.Timeline {
+		switch e.Type {
+		case EventReviewClaimed, EventReviewReleased, EventReviewed:
+			types = append(types, e.Type+":"+e.Actor)
+		}
+	}
+	want := []string{"review_claimed:carol", "review_released:carol", "review_claimed:dave", "reviewed:dave"}
+	if !reflect.DeepEqual(types, want) {
+		t.Errorf("review events = %q, want %q", types, want)
+	}
+
+	// Once approved the document has left the queue.
+	_, err = s.ApproveReview(dave, &pb.ApproveReviewRequest{DocumentId: id})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("second approval: err = %v, want FailedPrecondition", err)
+	}
+}
+
+func TestReviewerIsRequiredWithoutIdentity(t *testing.T) {
+	s := NewServer(nil, Options{})
+	_, err := s.ClaimReview(context.Background(), &pb.ClaimReviewRequest{DocumentId: "any"})
+	wantCode(t, err, codes.InvalidArgument)
+}
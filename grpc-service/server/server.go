@@ -6,145 +6,687 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
+	"sort"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/ryan-dayrit/nlp-pdf-extractor/health"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/logging"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/auth"
 	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/kafka"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/metrics"
 	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/pb"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/ratelimit"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/usage"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/validation"
+)
+
+// Document statuses.
+const (
+	StatusPending     = "pending"
+	StatusProcessing  = "processing"
+	StatusCompleted   = "completed"
+	StatusPartial     = "partial"
+	StatusFailed      = "failed"
+	StatusNeedsReview = "needs_review"
+)
+
+// Data point result statuses, as reported in pb.DataPointResult.Status.
+const (
+	DataPointFound     = "found"
+	DataPointNotFound  = "not_found"
+	DataPointAmbiguous = "ambiguous"
+	DataPointError     = "error"
+	DataPointSkipped   = "skipped"
 )
 
 // Document is the in-memory representation of an uploaded PDF.
 type Document struct {
 	ID         string
+	Tenant     string
 	Filename   string
 	Status     string
 	DataPoints []string
 	Results    map[string]string
+	Details    map[string]*pb.DataPointResult
 	PDFData    []byte
+
+	// Revisions holds every stored version of the results, oldest first.
+	// Results and Details always mirror the last revision.
+	Revisions []*Revision
+	// Violations lists the validation rules the current results fail.
+	Violations []validation.Violation
+	// ReviewReasons explains why the document was sent to human review.
+	ReviewReasons []string
+	// ClaimedBy is the reviewer currently working on the document, if any.
+	ClaimedBy string
+	ClaimedAt time.Time
+	// CreatedAt is when the document was uploaded.
+	CreatedAt time.Time
+	// QueuedAt is when the document was last uploaded or reprocessed.
+	QueuedAt time.Time
+	// Timeline records what happened to the document and when.
+	Timeline []Event
+	// Processing is set while a consumer reports working on the document.
+	Processing *Processing
+	// Republished counts the sweeper's republishes since the document was
+	// last queued, the last at SweptAt.
+	Republished int
+	SweptAt     time.Time
+	// PublishError is set while the document's last upload event failed
+	// to reach Kafka, at PublishFailedAt.
+	PublishError    string
+	PublishFailedAt time.Time
+	// FailureReason explains why the whole document failed: extraction
+	// failing outright, or the sweeper giving up on it, in which case
+	// TimedOut is set.
+	FailureReason string
+	TimedOut      bool
+}
+
+// errKafkaUnavailable is recorded when an upload cannot be published
+// because the service started without Kafka.
+var errKafkaUnavailable = errors.New("kafka unavailable at startup")
+
+// Options configures optional Server behaviour.
+type Options struct {
+	// Review decides which documents are routed to the human review queue.
+	Review ReviewPolicy
+	// Rules validates results as they are stored; nil disables validation.
+	Rules *validation.RuleSet
+	// Auth authenticates REST callers; nil leaves the REST API open. gRPC
+	// callers are authenticated by the interceptors in package auth.
+	Auth auth.Authenticator
+	// Callbacks verifies the consumer's signed result callbacks; nil
+	// rejects every callback.
+	Callbacks *auth.HMACVerifier
+	// Policy decides which roles may call each method; nil allows every
+	// caller everything.
+	Policy *auth.Policy
+	// RateLimits throttle REST callers; gRPC callers are throttled by the
+	// interceptors in package ratelimit.
+	RateLimits []*ratelimit.Limiter
+	// Admission rejects uploads and reprocessing while the pipeline is
+	// backed up.
+	Admission AdmissionPolicy
+	// Usage meters tenants' work; a fresh Meter is used when nil.
+	Usage *usage.Meter
+	// Quotas caps tenants' monthly usage; nil means unlimited.
+	Quotas *usage.Quotas
+	// UploadMemory is how many bytes of a multipart upload are held in
+	// memory, the rest spilling to temporary files; 0 means 32 MB.
+	UploadMemory int64
+	// HealthTimeout bounds each readiness check; 0 means 2s.
+	HealthTimeout time.Duration
+	// Sweeper republishes and finally fails documents stuck in the
+	// pipeline; see RunSweeper.
+	Sweeper SweeperPolicy
+	// LogLevel is served at /admin/log-level so it can be changed while
+	// running; nil leaves the route out.
+	LogLevel *slog.LevelVar
+	// DeadLetters reads the consumer's dead-letter topic for the admin API;
+	// nil makes those methods unavailable.
+	DeadLetters *kafka.DeadLetters
 }
 
 // Server holds the in-memory store, the Kafka producer, and serves both gRPC
 // and HTTP traffic.
 type Server struct {
-	mu       sync.RWMutex
-	docs     map[string]*Document
-	producer *kafka.Producer
+	mu        sync.RWMutex
+	docs      map[string]*Document
+	producer  *kafka.Producer
+	review    ReviewPolicy
+	rules     *validation.RuleSet
+	auth      auth.Authenticator
+	callbacks *auth.HMACVerifier
+	policy    *auth.Policy
+	limits    []*ratelimit.Limiter
+	admission AdmissionPolicy
+	usage     *usage.Meter
+	quotas    *usage.Quotas
+	uploadMem int64
+	health    *health.Checker
+	logLevel  *slog.LevelVar
+
+	sweeper    SweeperPolicy
+	sweepStats sweepStats
+
+	deadLetters *kafka.DeadLetters
+	// replayed records when each dead letter was last replayed.
+	replayed map[deadLetterKey]time.Time
 }
 
 // NewServer constructs a Server. producer may be nil if Kafka is unavailable.
-func NewServer(producer *kafka.Producer) *Server {
-	return &Server{
-		docs:     make(map[string]*Document),
-		producer: producer,
+func NewServer(producer *kafka.Producer, opts Options) *Server {
+	if opts.Usage == nil {
+		opts.Usage = usage.NewMeter()
+	}
+	if opts.UploadMemory <= 0 {
+		opts.UploadMemory = 32 << 20
+	}
+	if opts.HealthTimeout <= 0 {
+		opts.HealthTimeout = 2 * time.Second
 	}
+	s := &Server{
+		docs:      make(map[string]*Document),
+		producer:  producer,
+		review:    opts.Review,
+		rules:     opts.Rules,
+		auth:      opts.Auth,
+		callbacks: opts.Callbacks,
+		policy:    opts.Policy,
+		limits:    opts.RateLimits,
+		admission: opts.Admission,
+		usage:     opts.Usage,
+		quotas:    opts.Quotas,
+		uploadMem: opts.UploadMemory,
+		health:    health.NewChecker(opts.HealthTimeout),
+		logLevel:  opts.LogLevel,
+		sweeper:   opts.Sweeper,
+
+		deadLetters: opts.DeadLetters,
+		replayed:    make(map[deadLetterKey]time.Time),
+	}
+	s.health.Add("kafka", s.checkKafka)
+	s.health.Add("store", s.checkStore)
+	return s
 }
 
 // ---------------------------------------------------------------------------
 // gRPC service implementation
 // ---------------------------------------------------------------------------
 
-func (s *Server) UploadDocument(_ context.Context, req *pb.UploadDocumentRequest) (*pb.UploadDocumentResponse, error) {
-	id := uuid.New().String()
+func (s *Server) UploadDocument(ctx context.Context, req *pb.UploadDocumentRequest) (*pb.UploadDocumentResponse, error) {
+	if err := s.authorize(ctx, "UploadDocument"); err != nil {
+		return nil, err
+	}
+	if err := s.admit(ctx); err != nil {
+		metrics.Uploads.WithLabelValues(metrics.UploadRejectedAdmission).Inc()
+		return nil, err
+	}
+	tenant := auth.TenantFromContext(ctx)
+	if err := s.charge(tenant, len(req.PdfData)); err != nil {
+		metrics.Uploads.WithLabelValues(metrics.UploadRejectedQuota).Inc()
+		return nil, err
+	}
+
+	id, now := uuid.New().String(), time.Now()
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("document_id", id))
 	doc := &Document{
 		ID:         id,
+		Tenant:     tenant,
 		Filename:   req.Filename,
-		Status:     "pending",
+		Status:     StatusPending,
 		DataPoints: req.DataPoints,
 		PDFData:    req.PdfData,
 		Results:    make(map[string]string),
+		Details:    make(map[string]*pb.DataPointResult),
+		CreatedAt:  now,
+		QueuedAt:   now,
 	}
+	record(doc, EventUploaded, actorFrom(ctx), map[string]string{
+		"filename":    req.Filename,
+		"bytes":       strconv.Itoa(len(req.PdfData)),
+		"data_points": strconv.Itoa(len(req.DataPoints)),
+	})
 
 	s.mu.Lock()
 	s.docs[id] = doc
 	s.mu.Unlock()
 
+	outcome := metrics.UploadUnpublished
 	if s.producer != nil {
-		pdfBase64 := base64.StdEncoding.EncodeToString(req.PdfData)
-		if err := s.producer.PublishDocumentUpload(id, req.Filename, pdfBase64, req.DataPoints); err != nil {
-			log.Printf("warning: kafka publish failed: %v", err)
+		if err := s.publish(ctx, doc, req.PdfData, req.DataPoints); err != nil {
+			slog.WarnContext(ctx, "kafka publish failed; document stays pending", "document_id", id, "tenant", tenant, "error", err)
+		} else {
+			outcome = metrics.UploadAccepted
 		}
+	} else {
+		s.recordPublish(doc, 0, 0, errKafkaUnavailable)
 	}
+	metrics.Uploads.WithLabelValues(outcome).Inc()
 
-	return &pb.UploadDocumentResponse{DocumentId: id, Status: "pending"}, nil
+	return &pb.UploadDocumentResponse{DocumentId: id, Status: StatusPending}, nil
 }
 
-func (s *Server) GetDataPoints(_ context.Context, req *pb.GetDataPointsRequest) (*pb.GetDataPointsResponse, error) {
-	s.mu.RLock()
-	doc, ok := s.docs[req.DocumentId]
-	s.mu.RUnlock()
+// publish sends a document to the extraction pipeline and records the
+// outcome on its timeline. ID, Tenant and Filename never change, so doc may
+// be read without holding s.mu.
+func (s *Server) publish(ctx context.Context, doc *Document, pdfData []byte, dataPoints []string) error {
+	pdfBase64 := base64.StdEncoding.EncodeToString(pdfData)
+	partition, offset, err := s.producer.PublishDocumentUpload(ctx, doc.ID, doc.Tenant, doc.Filename, pdfBase64, dataPoints)
+	s.recordPublish(doc, partition, offset, err)
+	if err != nil {
+		metrics.KafkaPublishErrors.Inc()
+		return err
+	}
+	metrics.KafkaPublished.Inc()
+	return nil
+}
 
-	if !ok {
-		return nil, status.Errorf(codes.NotFound, "document %s not found", req.DocumentId)
+// authorize checks the caller in ctx against the access policy for op, an
+// ExtractorService method name.
+func (s *Server) authorize(ctx context.Context, op string) error {
+	if s.policy == nil {
+		return nil
 	}
+	id, _ := auth.FromContext(ctx)
+	if !s.policy.Allowed(id, op) {
+		who := "anonymous caller"
+		if id != nil {
+			who = id.Subject
+		}
+		return status.Errorf(codes.PermissionDenied, "%s may not call %s", who, op)
+	}
+	return nil
+}
 
-	results := make(map[string]string, len(doc.Results))
-	for k, v := range doc.Results {
-		results[k] = v
+// authorizeCrossTenant is authorize for operator methods, which see or
+// affect every tenant. They are refused without an access policy, which
+// would otherwise let any caller use them.
+func (s *Server) authorizeCrossTenant(ctx context.Context, op string) error {
+	if s.policy == nil {
+		return status.Errorf(codes.PermissionDenied, "%s requires an access policy that grants it", op)
+	}
+	return s.authorize(ctx, op)
+}
+
+// lookup returns the document with the given ID if it belongs to the
+// caller's tenant. Documents of other tenants are reported as not found so
+// their existence is not revealed. Callers must hold s.mu.
+func (s *Server) lookup(ctx context.Context, id string) (*Document, error) {
+	doc, ok := s.docs[id]
+	if !ok || doc.Tenant != auth.TenantFromContext(ctx) {
+		return nil, status.Errorf(codes.NotFound, "document %s not found", id)
+	}
+	return doc, nil
+}
+
+func (s *Server) DeleteDocument(ctx context.Context, req *pb.DeleteDocumentRequest) (*pb.DeleteDocumentResponse, error) {
+	if err := s.authorize(ctx, "DeleteDocument"); err != nil {
+		return nil, err
 	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.lookup(ctx, req.DocumentId); err != nil {
+		return nil, err
+	}
+	delete(s.docs, req.DocumentId)
+	return &pb.DeleteDocumentResponse{Status: "deleted"}, nil
+}
+
+// ReprocessDocument sends a stored document through extraction again. Its
+// revision history is kept; the new results become the next revision.
+func (s *Server) ReprocessDocument(ctx context.Context, req *pb.ReprocessDocumentRequest) (*pb.ReprocessDocumentResponse, error) {
+	if err := s.authorize(ctx, "ReprocessDocument"); err != nil {
+		return nil, err
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("document_id", req.DocumentId))
+
+	if s.producer == nil {
+		return nil, status.Error(codes.Unavailable, "kafka is unavailable; cannot reprocess")
+	}
+	if err := s.admit(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	doc, err := s.lookup(ctx, req.DocumentId)
+	if err == nil {
+		err = s.charge(doc.Tenant, len(doc.PDFData))
+	}
+	if err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	if len(req.DataPoints) > 0 {
+		doc.DataPoints = req.DataPoints
+	}
+	queue(doc)
+	record(doc, EventReprocessed, actorFrom(ctx), map[string]string{"data_points": strconv.Itoa(len(doc.DataPoints))})
+	pdfData, dataPoints := doc.PDFData, doc.DataPoints
+	s.mu.Unlock()
+
+	if err := s.publish(ctx, doc, pdfData, dataPoints); err != nil {
+		return nil, status.Errorf(codes.Unavailable, "publish: %v", err)
+	}
+	slog.InfoContext(ctx, "document requeued", "document_id", doc.ID, "tenant", doc.Tenant)
+	return &pb.ReprocessDocumentResponse{DocumentId: doc.ID, Status: StatusPending}, nil
+}
+
+// queue resets doc for another pass through extraction. Callers must hold
+// s.mu for writing.
+func queue(doc *Document) {
+	doc.Status = StatusPending
+	doc.ReviewReasons = nil
+	doc.ClaimedBy, doc.ClaimedAt = "", time.Time{}
+	doc.Processing = nil
+	doc.QueuedAt = time.Now()
+	doc.Republished, doc.SweptAt, doc.FailureReason, doc.TimedOut = 0, time.Time{}, "", false
+}
+
+func (s *Server) GetDataPoints(ctx context.Context, req *pb.GetDataPointsRequest) (*pb.GetDataPointsResponse, error) {
+	if err := s.authorize(ctx, "GetDataPoints"); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	doc, err := s.lookup(ctx, req.DocumentId)
+	if err != nil {
+		return nil, err
+	}
+
+	results, details := copyResults(doc)
 	return &pb.GetDataPointsResponse{
-		DocumentId: doc.ID,
-		Status:     doc.Status,
-		Results:    results,
+		DocumentId:    doc.ID,
+		Status:        doc.Status,
+		Results:       results,
+		Details:       details,
+		Revision:      int32(len(doc.Revisions)),
+		ReviewReasons: append([]string(nil), doc.ReviewReasons...),
+		Violations:    violationsToPB(doc.Violations),
+		Processing:    processingInfo(doc.Processing),
+		FailureReason: doc.FailureReason,
 	}, nil
 }
 
-func (s *Server) ListDocuments(_ context.Context, _ *pb.ListDocumentsRequest) (*pb.ListDocumentsResponse, error) {
+// ListDocuments returns the caller's tenant's documents ordered by ID. Pages
+// continue after the document ID carried in PageToken.
+func (s *Server) ListDocuments(ctx context.Context, req *pb.ListDocumentsRequest) (*pb.ListDocumentsResponse, error) {
+	if err := s.authorize(ctx, "ListDocuments"); err != nil {
+		return nil, err
+	}
+
+	if req.PageSize < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	tenant := auth.TenantFromContext(ctx)
 	summaries := make([]*pb.DocumentSummary, 0, len(s.docs))
 	for _, doc := range s.docs {
+		if doc.Tenant != tenant {
+			continue
+		}
+		if req.Status != "" && doc.Status != req.Status {
+			continue
+		}
+		if req.PageToken != "" && doc.ID <= req.PageToken {
+			continue
+		}
 		summaries = append(summaries, &pb.DocumentSummary{
-			DocumentId: doc.ID,
-			Filename:   doc.Filename,
-			Status:     doc.Status,
+			DocumentId:    doc.ID,
+			Filename:      doc.Filename,
+			Status:        doc.Status,
+			Processing:    processingInfo(doc.Processing),
+			FailureReason: doc.FailureReason,
 		})
 	}
-	return &pb.ListDocumentsResponse{Documents: summaries}, nil
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].DocumentId < summaries[j].DocumentId })
+
+	resp := &pb.ListDocumentsResponse{Documents: summaries}
+	if req.PageSize > 0 && len(summaries) > int(req.PageSize) {
+		resp.Documents = summaries[:req.PageSize]
+		resp.NextPageToken = resp.Documents[len(resp.Documents)-1].DocumentId
+	}
+	return resp, nil
 }
 
-func (s *Server) UpdateDataPoints(_ context.Context, req *pb.UpdateDataPointsRequest) (*pb.UpdateDataPointsResponse, error) {
+// UpdateDataPoints stores extraction results. It only accepts calls that
+// arrived through the signed HTTP callback, never directly over gRPC. The
+// request's TenantId, echoed from the Kafka event, must match the document's.
+func (s *Server) UpdateDataPoints(ctx context.Context, req *pb.UpdateDataPointsRequest) (*pb.UpdateDataPointsResponse, error) {
+	if err := s.authorize(ctx, "UpdateDataPoints"); err != nil {
+		return nil, err
+	}
+
+	if !auth.IsServiceCall(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "results may only be posted by the signed consumer callback")
+	}
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("document_id", req.DocumentId))
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	doc, ok := s.docs[req.DocumentId]
-	if !ok {
-		return nil, status.Errorf(codes.NotFound, "document %s not found", req.DocumentId)
+	doc, err := s.callbackDoc(req.DocumentId, req.TenantId)
+	if err != nil {
+		return nil, err
 	}
 
+	results, details := copyResults(doc)
 	for k, v := range req.Results {
-		doc.Results[k] = v
+		results[k] = v
+	}
+	for k, v := range req.Details {
+		if v == nil {
+			continue
+		}
+		details[k] = cloneDataPointResult(v)
+		if _, ok := req.Results[k]; !ok {
+			results[k] = v.Value
+		}
+	}
+	worker := req.WorkerId
+	if worker == "" {
+		worker = "consumer"
+	}
+	recordConsumerEvents(doc, worker, req.Events)
+	addRevision(doc, RevisionSourceExtractor, "", results, details)
+	s.usage.Add(doc.Tenant, 0, int64(req.Pages), 0)
+
+	doc.Status = documentStatus(doc.Details)
+	s.validate(doc)
+	doc.ReviewReasons = s.review.reasons(doc.Details)
+	for _, v := range doc.Violations {
+		doc.ReviewReasons = append(doc.ReviewReasons, "validation: "+v.Message)
+	}
+	doc.ClaimedBy, doc.ClaimedAt = "", time.Time{}
+	doc.Processing = nil
+	doc.FailureReason, doc.TimedOut = "", false
+	if req.Error != "" {
+		// The extractor produced nothing usable, which an empty set of
+		// details would otherwise report as completed.
+		doc.Status = StatusFailed
+		doc.FailureReason = "extraction failed: " + req.Error
+	}
+	if len(doc.ReviewReasons) > 0 && doc.Status != StatusFailed {
+		doc.Status = StatusNeedsReview
 	}
-	doc.Status = "completed"
 
+	record(doc, EventResultsStored, worker, map[string]string{
+		"status":   doc.Status,
+		"revision": strconv.Itoa(len(doc.Revisions)),
+	})
+	slog.InfoContext(ctx, "results stored", "document_id", doc.ID, "tenant", doc.Tenant,
+		"status", doc.Status, "revision", len(doc.Revisions))
+	recordResults(req)
+	if !doc.QueuedAt.IsZero() {
+		metrics.ExtractionDuration.WithLabelValues(doc.Status).Observe(time.Since(doc.QueuedAt).Seconds())
+	}
 	return &pb.UpdateDataPointsResponse{Status: "updated"}, nil
 }
 
+// recordResults counts each data point reported by the extractor by status.
+// Details without a status, and bare non-empty results, count as found.
+func recordResults(req *pb.UpdateDataPointsRequest) {
+	for name, d := range req.Details {
+		if d == nil {
+			continue
+		}
+		state := d.Status
+		if state == "" {
+			state = DataPointFound
+		}
+		metrics.DataPointResult(name, state)
+	}
+	for name, v := range req.Results {
+		if d, ok := req.Details[name]; ok && d != nil {
+			continue
+		}
+		state := DataPointFound
+		if v == "" {
+			state = DataPointNotFound
+		}
+		metrics.DataPointResult(name, state)
+	}
+}
+
+// DocumentCounts returns the number of stored documents in each status.
+func (s *Server) DocumentCounts() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	counts := make(map[string]int)
+	for _, doc := range s.docs {
+		counts[doc.Status]++
+	}
+	return counts
+}
+
+// validate re-evaluates the rule set against doc's current results. Callers
+// must hold s.mu for writing.
+func (s *Server) validate(doc *Document) {
+	dataPoints := append([]string(nil), doc.DataPoints...)
+	for dp := range doc.Results {
+		dataPoints = append(dataPoints, dp)
+	}
+	doc.Violations = s.rules.Validate(dataPoints, doc.Results)
+}
+
+func violationsToPB(vs []validation.Violation) []*pb.Violation {
+	out := make([]*pb.Violation, 0, len(vs))
+	for _, v := range vs {
+		out = append(out, &pb.Violation{
+			Rule:       v.Rule,
+			DataPoints: append([]string(nil), v.DataPoints...),
+			Message:    v.Message,
+		})
+	}
+	return out
+}
+
+// documentStatus derives the document-level status from its per-data-point
+// results: completed when every data point produced a value, failed when
+// every data point errored, and partial otherwise. Results without a status
+// (older callers that only send plain values) count as found.
+func documentStatus(details map[string]*pb.DataPointResult) string {
+	var ok, errored int
+	for _, d := range details {
+		switch d.Status {
+		case "", DataPointFound, DataPointAmbiguous:
+			ok++
+		case DataPointError:
+			errored++
+		}
+	}
+	switch {
+	case ok == len(details):
+		return StatusCompleted
+	case errored == len(details):
+		return StatusFailed
+	default:
+		return StatusPartial
+	}
+}
+
+// cloneDataPointResult returns a deep copy of r so stored results are never
+// shared with callers.
+func cloneDataPointResult(r *pb.DataPointResult) *pb.DataPointResult {
+	c := *r
+	if r.Bbox != nil {
+		bbox := *r.Bbox
+		c.Bbox = &bbox
+	}
+	c.Candidates = make([]*pb.Candidate, 0, len(r.Candidates))
+	for _, cand := range r.Candidates {
+		if cand == nil {
+			continue
+		}
+		cc := *cand
+		if cand.Bbox != nil {
+			bbox := *cand.Bbox
+			cc.Bbox = &bbox
+		}
+		c.Candidates = append(c.Candidates, &cc)
+	}
+	return &c
+}
+
 // ---------------------------------------------------------------------------
 // HTTP REST server
 // ---------------------------------------------------------------------------
 
-// NewHTTPMux builds a net/http ServeMux with all REST routes and CORS middleware.
+// NewHTTPMux builds a net/http ServeMux with all REST routes, authentication
+// (when configured) and CORS middleware.
 func (s *Server) NewHTTPMux() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("POST /documents", s.handleUploadDocument)
 	mux.HandleFunc("GET /documents", s.handleListDocuments)
 	mux.HandleFunc("GET /documents/{id}/datapoints", s.handleGetDataPoints)
-	mux.HandleFunc("POST /documents/{id}/datapoints", s.handleUpdateDataPoints)
-	return corsMiddleware(mux)
+	mux.HandleFunc("DELETE /documents/{id}", s.handleDeleteDocument)
+	mux.HandleFunc("POST /documents/{id}/reprocess", s.handleReprocessDocument)
+	mux.HandleFunc("GET /documents/{id}/revisions", s.handleListRevisions)
+	mux.HandleFunc("GET /documents/{id}/timeline", s.handleGetTimeline)
+	mux.HandleFunc("GET /review/queue", s.handleListReviewQueue)
+	mux.HandleFunc("POST /review/{id}/claim", s.handleClaimReview)
+	mux.HandleFunc("POST /review/{id}/approve", s.handleApproveReview)
+	mux.HandleFunc("POST /review/{id}/release", s.handleReleaseReview)
+	mux.HandleFunc("GET /usage", s.handleGetUsage)
+	mux.HandleFunc("GET /usage/export", s.handleExportUsage)
+	if s.logLevel != nil {
+		mux.HandleFunc("GET /admin/log-level", s.handleLogLevel)
+		mux.HandleFunc("PUT /admin/log-level", s.handleLogLevel)
+	}
+	mux.HandleFunc("GET /admin/sweeper", s.handleGetSweeperStatus)
+	mux.HandleFunc("GET /admin/documents/counts", s.handleGetDocumentCounts)
+	mux.HandleFunc("POST /admin/documents/requeue", s.handleRequeueDocuments)
+	mux.HandleFunc("POST /admin/documents/purge", s.handlePurgeDocuments)
+	mux.HandleFunc("GET /admin/outbox", s.handleListOutbox)
+	mux.HandleFunc("GET /admin/dead-letters", s.handleListDeadLetters)
+	mux.HandleFunc("POST /admin/dead-letters/replay", s.handleReplayDeadLetters)
+
+	var public http.Handler = mux
+	if len(s.limits) > 0 {
+		public = ratelimit.Middleware(s.limits, public)
+	}
+	if s.auth != nil {
+		public = auth.Middleware(s.auth, public)
+	}
+
+	// The consumer's result and progress callbacks are authenticated by
+	// their HMAC signature instead of caller credentials.
+	root := http.NewServeMux()
+	health.Register(root, s.health)
+	root.Handle("GET /metrics", metrics.Handler())
+	root.Handle("POST /documents/{id}/datapoints", auth.HMACMiddleware(s.callbacks, http.HandlerFunc(s.handleUpdateDataPoints)))
+	root.Handle("POST /documents/{id}/progress", auth.HMACMiddleware(s.callbacks, http.HandlerFunc(s.handleReportProgress)))
+	root.Handle("/", public)
+	return corsMiddleware(root)
 }
 
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
 		if r.Method == http.MethodOptions {
 			w.WriteHeader(http.StatusNoContent)
 			return
@@ -157,13 +699,37 @@ func writeJSON(w http.ResponseWriter, code int, v interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
 	if err := json.NewEncoder(w).Encode(v); err != nil {
-		log.Printf("writeJSON encode error: %v", err)
+		slog.Error("writeJSON encode error", "error", err)
+	}
+}
+
+// writeError reports err as plain text, mapping gRPC status codes onto the
+// closest HTTP status.
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusInternalServerError
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		code = http.StatusBadRequest
+	case codes.NotFound:
+		code = http.StatusNotFound
+	case codes.FailedPrecondition, codes.AlreadyExists, codes.Aborted:
+		code = http.StatusConflict
+	case codes.Unavailable:
+		code = http.StatusServiceUnavailable
+	case codes.Unauthenticated:
+		code = http.StatusUnauthorized
+	case codes.PermissionDenied:
+		code = http.StatusForbidden
+	case codes.ResourceExhausted:
+		code = http.StatusTooManyRequests
+		setRetryAfter(w, err)
 	}
+	http.Error(w, status.Convert(err).Message(), code)
 }
 
 // POST /documents — multipart form: field "file" (PDF), field "data_points" (JSON array string)
 func (s *Server) handleUploadDocument(w http.ResponseWriter, r *http.Request) {
-	if err := r.ParseMultipartForm(32 << 20); err != nil {
+	if err := r.ParseMultipartForm(s.uploadMem); err != nil {
 		http.Error(w, "failed to parse multipart form", http.StatusBadRequest)
 		return
 	}
@@ -195,15 +761,29 @@ func (s *Server) handleUploadDocument(w http.ResponseWriter, r *http.Request) {
 		DataPoints: dataPoints,
 	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusCreated, resp)
 }
 
-// GET /documents — list all documents
+// GET /documents — list documents; optional query: page_size, page_token, status
 func (s *Server) handleListDocuments(w http.ResponseWriter, r *http.Request) {
-	resp, _ := s.ListDocuments(r.Context(), &pb.ListDocumentsRequest{})
+	q := r.URL.Query()
+	req := &pb.ListDocumentsRequest{PageToken: q.Get("page_token"), Status: q.Get("status")}
+	if v := q.Get("page_size"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 32)
+		if err != nil {
+			http.Error(w, "page_size must be an integer", http.StatusBadRequest)
+			return
+		}
+		req.PageSize = int32(n)
+	}
+	resp, err := s.ListDocuments(r.Context(), req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
 	writeJSON(w, http.StatusOK, resp)
 }
 
@@ -212,19 +792,26 @@ func (s *Server) handleGetDataPoints(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	resp, err := s.GetDataPoints(r.Context(), &pb.GetDataPointsRequest{DocumentId: id})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusOK, resp)
 }
 
-// POST /documents/{id}/datapoints — called by the NLP consumer to store results
-// Body: {"results": {"key": "value", ...}}
+// POST /documents/{id}/datapoints — called by the NLP consumer to store results;
+// requests must be HMAC-signed (see auth.Sign)
+// Body: {"tenant_id": "...", "results": {"key": "value", ...}, "details": {"key": {"value": ..., "confidence": ...}, ...}, "error": "..."}
 func (s *Server) handleUpdateDataPoints(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 
 	var body struct {
-		Results map[string]string `json:"results"`
+		TenantID string                         `json:"tenant_id"`
+		Pages    int32                          `json:"pages"`
+		Results  map[string]string              `json:"results"`
+		Details  map[string]*pb.DataPointResult `json:"details"`
+		WorkerID string                         `json:"worker_id"`
+		Events   []*pb.TimelineEvent            `json:"events"`
+		Error    string                         `json:"error"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 		http.Error(w, "invalid JSON body", http.StatusBadRequest)
@@ -233,11 +820,64 @@ func (s *Server) handleUpdateDataPoints(w http.ResponseWriter, r *http.Request)
 
 	resp, err := s.UpdateDataPoints(r.Context(), &pb.UpdateDataPointsRequest{
 		DocumentId: id,
+		TenantId:   body.TenantID,
+		Pages:      body.Pages,
 		Results:    body.Results,
+		Details:    body.Details,
+		WorkerId:   body.WorkerID,
+		Events:     body.Events,
+		Error:      body.Error,
 	})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// DELETE /documents/{id} — remove a document and its results
+func (s *Server) handleDeleteDocument(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.DeleteDocument(r.Context(), &pb.DeleteDocumentRequest{DocumentId: r.PathValue("id")})
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 	writeJSON(w, http.StatusOK, resp)
 }
+
+// POST /documents/{id}/reprocess — re-run extraction
+// Body (optional): {"data_points": ["key", ...]}
+func (s *Server) handleReprocessDocument(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		DataPoints []string `json:"data_points"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && err != io.EOF {
+			http.Error(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+	}
+	resp, err := s.ReprocessDocument(r.Context(), &pb.ReprocessDocumentRequest{
+		DocumentId: r.PathValue("id"),
+		DataPoints: body.DataPoints,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, resp)
+}
+
+// GET /admin/log-level — report the log level
+// PUT /admin/log-level — change it; body: {"level": "debug"}
+func (s *Server) handleLogLevel(w http.ResponseWriter, r *http.Request) {
+	op := "GetLogLevel"
+	if r.Method == http.MethodPut {
+		op = "SetLogLevel"
+	}
+	if err := s.authorizeCrossTenant(r.Context(), op); err != nil {
+		writeError(w, err)
+		return
+	}
+	logging.LevelHandler(s.logLevel).ServeHTTP(w, r)
+}
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/auth"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/pb"
+)
+
+// testPolicy mirrors policy.example.json.
+func testPolicy() *auth.Policy {
+	return &auth.Policy{Roles: map[string][]string{
+		auth.RoleUploader: {"UploadDocument", "GetDataPoints", "ListDocuments", "ListRevisions", "GetTimeline", "GetUsage"},
+		auth.RoleReviewer: {"GetDataPoints", "ListDocuments", "ListRevisions", "GetTimeline", "ListReviewQueue", "ClaimReview", "ApproveReview", "ReleaseReview"},
+		auth.RoleAdmin:    {"*"},
+		auth.RoleConsumer: {"UpdateDataPoints", "ReportProgress"},
+	}}
+}
+
+// as returns a context for subject acting for tenant with roles.
+func as(subject, tenant string, roles ...string) context.Context {
+	return auth.NewContext(context.Background(), &auth.Identity{Subject: subject, Tenant: tenant, Roles: roles})
+}
+
+// consumerCall returns the context of a signed consumer callback.
+func consumerCall() context.Context {
+	return auth.NewContext(context.Background(), &auth.Identity{Subject: "consumer", Method: auth.MethodHMAC, Roles: []string{auth.RoleConsumer}})
+}
+
+// upload stores a document for the caller in ctx and returns its ID.
+func upload(t *testing.T, s *Server, ctx context.Context, dataPoints ...string) string {
+	t.Helper()
+	resp, err := s.UploadDocument(ctx, &pb.UploadDocumentRequest{Filename: "invoice.pdf", PdfData: []byte("%PDF-1.7"), DataPoints: dataPoints})
+	if err != nil {
+		t.Fatalf("UploadDocument: %v", err)
+	}
+	return resp.DocumentId
+}
+
+func wantCode(t *testing.T, err error, want codes.Code) {
+	t.Helper()
+	if got := status.Code(err); got != want {
+		t.Fatalf("err = %v, want code %s", err, want)
+	}
+}
+
+func TestOtherTenantsDocumentsAreNotFound(t *testing.T) {
+	s := NewServer(nil, Options{Policy: testPolicy()})
+	alice := as("alice", "acme", auth.RoleUploader, auth.RoleReviewer)
+	id := upload(t, s, alice, "total")
+	mallory := as("mallory", "globex", auth.RoleUploader, auth.RoleReviewer)
+
+	calls := map[string]func(context.Context) error{
+		"GetDataPoints": func(ctx context.Context) error {
+			_, err := s.GetDataPoints(ctx, &pb.GetDataPointsRequest{DocumentId: id})
+			return err
+		},
+		"GetTimeline": func(ctx context.Context) error {
+			_, err := s.GetTimeline(ctx, &pb.GetTimelineRequest{DocumentId: id})
+			return err
+		},
+		"ListRevisions": func(ctx context.Context) error {
+			_, err := s.ListRevisions(ctx, &pb.ListRevisionsRequest{DocumentId: id})
+			return err
+		},
+		"ClaimReview": func(ctx context.Context) error {
+			_, err := s.ClaimReview(ctx, &pb.ClaimReviewRequest{DocumentId: id})
+			return err
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			wantCode(t, call(mallory), codes.NotFound)
+		})
+	}
+
+	// The owner still sees it; a missing ID looks the same as another
+	// tenant's.
+	if _, err := s.GetDataPoints(alice, &pb.GetDataPointsRequest{DocumentId: id}); err != nil {
+		t.Fatalf("owner: %v", err)
+	}
+	_, err := s.GetDataPoints(mallory, &pb.GetDataPointsRequest{DocumentId: "no-such-id"})
+	wantCode(t, err, codes.NotFound)
+
+	list, err := s.ListDocuments(mallory, &pb.ListDocumentsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Documents) != 0 {
+		t.Errorf("other tenant lists %d documents", len(list.Documents))
+	}
+
+	// Deleting across tenants fails too and leaves the document alone.
+	_, err = s.DeleteDocument(as("mallory", "globex", auth.RoleAdmin), &pb.DeleteDocumentRequest{DocumentId: id})
+	wantCode(t, err, codes.NotFound)
+	if _, ok := s.docs[id]; !ok {
+		t.Error("document deleted by another tenant")
+	}
+}
+
+func TestCallbacksMustNameTheDocumentsTenant(t *testing.T) {
+	s := NewServer(nil, Options{Policy: testPolicy()})
+	id := upload(t, s, as("alice", "acme", auth.RoleUploader), "total")
+
+	_, err := s.UpdateDataPoints(consumerCall(), &pb.UpdateDataPointsRequest{DocumentId: id, TenantId: "globex", Results: map[string]string{"total": "1"}})
+	wantCode(t, err, codes.NotFound)
+	if _, err := s.UpdateDataPoints(consumerCall(), &pb.UpdateDataPointsRequest{DocumentId: id, TenantId: "acme", Results: map[string]string{"total": "1"}}); err != nil {
+		t.Fatalf("own tenant: %v", err)
+	}
+}
+
+func TestRolesGrantMethods(t *testing.T) {
+	s := NewServer(nil, Options{Policy: testPolicy()})
+	uploaded := upload(t, s, as("alice", "acme", auth.RoleUploader))
+
+	upload := func(ctx context.Context) error {
+		_, err := s.UploadDocument(ctx, &pb.UploadDocumentRequest{Filename: "a.pdf", PdfData: []byte("%PDF")})
+		return err
+	}
+	queue := func(ctx context.Context) error {
+		_, err := s.ListReviewQueue(ctx, &pb.ListReviewQueueRequest{})
+		return err
+	}
+	reprocess := func(ctx context.Context) error {
+		_, err := s.ReprocessDocument(ctx, &pb.ReprocessDocumentRequest{DocumentId: uploaded})
+		return err
+	}
+	results := func(ctx context.Context) error {
+		_, err := s.UpdateDataPoints(ctx, &pb.UpdateDataPointsRequest{DocumentId: uploaded, TenantId: "acme"})
+		return err
+	}
+	counts := func(ctx context.Context) error {
+		_, err := s.GetDocumentCounts(ctx, &pb.GetDocumentCountsRequest{})
+		return err
+	}
+
+	tests := []struct {
+		name string
+		role string // empty for a caller without roles
+		call func(context.Context) error
+		want codes.Code
+	}{
+		{"uploader uploads", auth.RoleUploader, upload, codes.OK},
+		{"uploader may not review", auth.RoleUploader, queue, codes.PermissionDenied},
+		{"uploader may not reprocess", auth.RoleUploader, reprocess, codes.PermissionDenied},
+		{"uploader may not post results", auth.RoleUploader, results, codes.PermissionDenied},
+		{"uploader may not count documents", auth.RoleUploader, counts, codes.PermissionDenied},
+		{"reviewer reviews", auth.RoleReviewer, queue, codes.OK},
+		{"reviewer may not upload", auth.RoleReviewer, upload, codes.PermissionDenied},
+		{"consumer may not upload", auth.RoleConsumer, upload, codes.PermissionDenied},
+		// The consumer role alone is not enough: results must come signed.
+		{"consumer role without signature", auth.RoleConsumer, results, codes.PermissionDenied},
+		{"admin uploads", auth.RoleAdmin, upload, codes.OK},
+		{"admin counts documents", auth.RoleAdmin, counts, codes.OK},
+		// Reprocess passes authorization and fails for want of Kafka.
+		{"admin reprocesses", auth.RoleAdmin, reprocess, codes.Unavailable},
+		{"no roles", "", upload, codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var roles []string
+			if tt.role != "" {
+				roles = []string{tt.role}
+			}
+			wantCode(t, tt.call(as("bob", "acme", roles...)), tt.want)
+		})
+	}
+}
+
+func TestWithoutPolicy(t *testing.T) {
+	s := NewServer(nil, Options{})
+	anon := context.Background()
+
+	// Tenant methods are open...
+	if _, err := s.UploadDocument(anon, &pb.UploadDocumentRequest{Filename: "a.pdf", PdfData: []byte("%PDF")}); err != nil {
+		t.Fatalf("UploadDocument: %v", err)
+	}
+	// ...but operator methods, which cross tenants, are not.
+	for op, call := range map[string]func() error{
+		"GetDocumentCounts": func() error { _, err := s.GetDocumentCounts(anon, &pb.GetDocumentCountsRequest{}); return err },
+		"GetSweeperStatus":  func() error { _, err := s.GetSweeperStatus(anon, &pb.GetSweeperStatusRequest{}); return err },
+		"ExportUsage":       func() error { _, err := s.ExportUsage(anon, &pb.ExportUsageRequest{}); return err },
+		"ListOutbox":        func() error { _, err := s.ListOutbox(anon, &pb.ListOutboxRequest{}); return err },
+	} {
+		if err := call(); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("%s: err = %v, want PermissionDenied", op, err)
+		}
+	}
+}
+
+func TestResultsStatus(t *testing.T) {
+	found := &pb.DataPointResult{Value: "1", Status: DataPointFound, Confidence: 0.9}
+	tests := []struct {
+		name    string
+		req     *pb.UpdateDataPointsRequest
+		status  string
+		failure string
+	}{
+		{"all found", &pb.UpdateDataPointsRequest{Details: map[string]*pb.DataPointResult{"total": found}}, StatusCompleted, ""},
+		{"some missing", &pb.UpdateDataPointsRequest{Details: map[string]*pb.DataPointResult{
+			"total": found, "date": {Status: DataPointNotFound},
+		}}, StatusPartial, ""},
+		{"low confidence", &pb.UpdateDataPointsRequest{Details: map[string]*pb.DataPointResult{
+			"total": {Value: "1", Status: DataPointFound, Confidence: 0.3},
+		}}, StatusNeedsReview, ""},
+		// Nothing came back, which would otherwise count as completed.
+		{"extraction error", &pb.UpdateDataPointsRequest{Error: "no text layer"}, StatusFailed, "extraction failed: no text layer"},
+		{"extraction error wins over review", &pb.UpdateDataPointsRequest{Error: "timeout", Details: map[string]*pb.DataPointResult{
+			"total": {Value: "1", Status: DataPointFound, Confidence: 0.3},
+		}}, StatusFailed, "extraction failed: timeout"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(nil, Options{Review: ReviewPolicy{MinConfidence: 0.5}})
+			tt.req.DocumentId = upload(t, s, context.Background(), "total", "date")
+			tt.req.TenantId = auth.DefaultTenant
+			if _, err := s.UpdateDataPoints(consumerCall(), tt.req); err != nil {
+				t.Fatalf("UpdateDataPoints: %v", err)
+			}
+			doc := s.docs[tt.req.DocumentId]
+			if doc.Status != tt.status || doc.FailureReason != tt.failure {
+				t.Errorf("status %s, failure %q; want %s, %q", doc.Status, doc.FailureReason, tt.status, tt.failure)
+			}
+		})
+	}
+}
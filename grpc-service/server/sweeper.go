@@ -0,0 +1,215 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/logging"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/metrics"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/pb"
+)
+
+// SweeperPolicy decides when a document is stuck and what is done about
+// it. A zero SLA disables the sweeper.
+type SweeperPolicy struct {
+	// SLA is how long a pending or processing document may go without
+	// progress: no upload, reprocess, republish or progress report.
+	SLA time.Duration
+	// MaxRepublish is how many times a stuck document is sent back to
+	// Kafka before it is marked failed.
+	MaxRepublish int
+	// Interval is the time between sweeps.
+	Interval time.Duration
+}
+
+// sweeperActor is the timeline actor for the sweeper's events.
+const sweeperActor = "sweeper"
+
+// sweepStats is what the sweeper has done since startup. It is guarded by
+// s.mu.
+type sweepStats struct {
+	lastRun     time.Time
+	stuck       int
+	republished int64
+	timedOut    int64
+}
+
+// lastActivity is the last sign that doc is moving through the pipeline.
+func lastActivity(doc *Document) time.Time {
+	t := doc.QueuedAt
+	if doc.SweptAt.After(t) {
+		t = doc.SweptAt
+	}
+	if doc.Processing != nil && doc.Processing.UpdatedAt.After(t) {
+		t = doc.Processing.UpdatedAt
+	}
+	return t
+}
+
+// RunSweeper sweeps every Interval until ctx is cancelled. It returns at
+// once when the sweeper is disabled.
+func (s *Server) RunSweeper(ctx context.Context) {
+	if s.sweeper.SLA <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.sweeper.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		s.sweep(ctx, time.Now())
+	}
+}
+
+// sweep republishes documents that have made no progress within the SLA,
+// and marks those already republished MaxRepublish times failed.
+func (s *Server) sweep(ctx context.Context, now time.Time) {
+	type job struct {
+		doc        *Document
+		pdfData    []byte
+		dataPoints []string
+	}
+	var jobs []job
+	p := s.sweeper
+
+	s.mu.Lock()
+	stuck := 0
+	for _, doc := range s.docs {
+		if doc.Status != StatusPending && doc.Status != StatusProcessing {
+			continue
+		}
+		idle := now.Sub(lastActivity(doc))
+		if idle < p.SLA {
+			continue
+		}
+		stuck++
+		idleText := idle.Round(time.Second).String()
+		if doc.Republished >= p.MaxRepublish {
+			doc.FailureReason = fmt.Sprintf("timed out: no progress for %s (republishes: %d)", idleText, doc.Republished)
+			doc.TimedOut = true
+			record(doc, EventTimedOut, sweeperActor, map[string]string{"previous_status": doc.Status, "idle": idleText})
+			doc.Status = StatusFailed
+			doc.Processing = nil
+			s.sweepStats.timedOut++
+			metrics.SweeperTimedOut.Inc()
+			slog.WarnContext(ctx, "sweeper: document timed out", "document_id", doc.ID, "tenant", doc.Tenant,
+				"idle", idleText, "republished", doc.Republished)
+			continue
+		}
+		doc.Republished++
+		record(doc, EventRepublished, sweeperActor, map[string]string{
+			"previous_status": doc.Status,
+			"idle":            idleText,
+			"attempt":         strconv.Itoa(doc.Republished),
+		})
+		doc.Status = StatusPending
+		doc.Processing = nil
+		doc.SweptAt = now
+		s.sweepStats.republished++
+		metrics.SweeperRepublished.Inc()
+		jobs = append(jobs, job{doc, doc.PDFData, doc.DataPoints})
+	}
+	s.sweepStats.lastRun = now
+	s.sweepStats.stuck = stuck
+	s.mu.Unlock()
+	metrics.SweeperStuck.Set(float64(stuck))
+
+	// Republished events carry a request ID per sweep so the consumer's
+	// logs can be tied back to it.
+	ctx = logging.WithRequestID(ctx, logging.NewRequestID())
+	for _, j := range jobs {
+		if s.producer == nil {
+			s.recordPublish(j.doc, 0, 0, errKafkaUnavailable)
+			continue
+		}
+		if err := s.publish(ctx, j.doc, j.pdfData, j.dataPoints); err != nil {
+			slog.WarnContext(ctx, "sweeper: republish failed", "document_id", j.doc.ID, "tenant", j.doc.Tenant, "error", err)
+			continue
+		}
+		slog.InfoContext(ctx, "sweeper: document republished", "document_id", j.doc.ID, "tenant", j.doc.Tenant)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// gRPC service implementation
+// ---------------------------------------------------------------------------
+
+// GetSweeperStatus reports the sweeper's settings and activity, and the
+// documents it is watching across every tenant, least recently active
+// first. It is meant for operators, so it is refused unless an access
+// policy grants it.
+func (s *Server) GetSweeperStatus(ctx context.Context, req *pb.GetSweeperStatusRequest) (*pb.GetSweeperStatusResponse, error) {
+	if err := s.authorizeCrossTenant(ctx, "GetSweeperStatus"); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, st := s.sweeper, s.sweepStats
+	resp := &pb.GetSweeperStatusResponse{
+		Enabled:         p.SLA > 0,
+		SlaSeconds:      int64(p.SLA / time.Second),
+		IntervalSeconds: int64(p.Interval / time.Second),
+		MaxRepublish:    int32(p.MaxRepublish),
+		Stuck:           int32(st.stuck),
+		Republished:     st.republished,
+		TimedOut:        st.timedOut,
+		Documents:       []*pb.SweptDocument{},
+	}
+	if !st.lastRun.IsZero() {
+		resp.LastRun = st.lastRun.UTC().Format(timelineTimeFormat)
+	}
+
+	now := time.Now()
+	for _, doc := range s.docs {
+		inFlight := doc.Status == StatusPending || doc.Status == StatusProcessing
+		last := lastActivity(doc)
+		overdue := inFlight && p.SLA > 0 && now.Sub(last) >= p.SLA
+		if !overdue && !doc.TimedOut && (!inFlight || doc.Republished == 0) {
+			continue
+		}
+		sd := &pb.SweptDocument{
+			DocumentId:    doc.ID,
+			TenantId:      doc.Tenant,
+			Status:        doc.Status,
+			LastActivity:  last.UTC().Format(timelineTimeFormat),
+			Overdue:       overdue,
+			Republished:   int32(doc.Republished),
+			FailureReason: doc.FailureReason,
+		}
+		if inFlight {
+			sd.IdleSeconds = int64(now.Sub(last) / time.Second)
+		}
+		resp.Documents = append(resp.Documents, sd)
+	}
+	// The fixed-width UTC timestamps sort chronologically as strings.
+	sort.Slice(resp.Documents, func(i, j int) bool {
+		return resp.Documents[i].LastActivity < resp.Documents[j].LastActivity
+	})
+	return resp, nil
+}
+
+// ---------------------------------------------------------------------------
+// HTTP REST handlers
+// ---------------------------------------------------------------------------
+
+// GET /admin/sweeper — the stuck-document sweeper's settings, activity and
+// watched documents
+func (s *Server) handleGetSweeperStatus(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.GetSweeperStatus(r.Context(), &pb.GetSweeperStatusRequest{})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
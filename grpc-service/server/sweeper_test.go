@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/auth"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/kafka"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/pb"
+)
+
+// newTestProducer returns a producer whose publishes a mock broker accepts.
+func newTestProducer(t *testing.T) *kafka.Producer {
+	t.Helper()
+	broker := sarama.NewMockBroker(t, 1)
+	t.Cleanup(broker.Close)
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("document-uploads", 0, broker.BrokerID()),
+		"ProduceRequest": sarama.NewMockProduceResponse(t),
+	})
+	p, err := kafka.NewProducer([]string{broker.Addr()}, "document-uploads")
+	if err != nil {
+		t.Fatalf("NewProducer: %v", err)
+	}
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func countEvents(doc *Document, typ string) int {
+	n := 0
+	for _, e := range doc.Timeline {
+		if e.Type == typ {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSweepRepublishesThenTimesOut(t *testing.T) {
+	const sla = 10 * time.Minute
+	s := NewServer(nil, Options{Policy: testPolicy(), Sweeper: SweeperPolicy{SLA: sla, MaxRepublish: 2, Interval: time.Minute}})
+	id := upload(t, s, as("alice", "acme", auth.RoleUploader))
+	doc := s.docs[id]
+	start := doc.QueuedAt
+
+	// Each step sweeps at start+at and checks the document afterwards.
+	steps := []struct {
+		at          time.Duration
+		status      string
+		republished int
+	}{
+		{sla - time.Second, StatusPending, 0},
+		{sla, StatusPending, 1},
+		// The republish restarts the clock.
+		{sla + sla/2, StatusPending, 1},
+		{2 * sla, StatusPending, 2},
+		{3 * sla, StatusFailed, 2},
+		// A failed document is no longer swept.
+		{10 * sla, StatusFailed, 2},
+	}
+	for _, st := range steps {
+		s.sweep(context.Background(), start.Add(st.at))
+		if doc.Status != st.status || doc.Republished != st.republished {
+			t.Fatalf("at +%s: status %s, republished %d; want %s, %d", st.at, doc.Status, doc.Republished, st.status, st.republished)
+		}
+	}
+
+	if !doc.TimedOut || doc.FailureReason != "timed out: no progress for 10m0s (republishes: 2)" {
+		t.Errorf("timed out %v, reason %q", doc.TimedOut, doc.FailureReason)
+	}
+	if n := countEvents(doc, EventRepublished); n != 2 {
+		t.Errorf("%d republished events, want 2", n)
+	}
+	if n := countEvents(doc, EventTimedOut); n != 1 {
+		t.Errorf("%d timed_out events, want 1", n)
+	}
+	// Without Kafka each republish is left in the outbox.
+	if doc.PublishError == "" {
+		t.Error("republish without a producer left no publish error")
+	}
+
+	resp, err := s.GetSweeperStatus(as("root", "ops", auth.RoleAdmin), &pb.GetSweeperStatusRequest{})
+	if err != nil {
+		t.Fatalf("GetSweeperStatus: %v", err)
+	}
+	if resp.Republished != 2 || resp.TimedOut != 1 || resp.Stuck != 0 || len(resp.Documents) != 1 || !resp.Enabled {
+		t.Errorf("status = %+v", resp)
+	}
+}
+
+func TestSweepWaitsForProgress(t *testing.T) {
+	const sla = time.Minute
+	s := NewServer(nil, Options{Sweeper: SweeperPolicy{SLA: sla, MaxRepublish: 1}})
+	id := upload(t, s, context.Background())
+	doc := s.docs[id]
+	start := doc.QueuedAt
+
+	doc.Status = StatusProcessing
+	doc.Processing = &Processing{Worker: "w1", StartedAt: start, UpdatedAt: start.Add(50 * time.Second)}
+	s.sweep(context.Background(), start.Add(sla))
+	if doc.Republished != 0 || doc.Status != StatusProcessing {
+		t.Fatalf("swept a document that reported progress: status %s, republished %d", doc.Status, doc.Republished)
+	}
+
+	s.sweep(context.Background(), start.Add(50*time.Second+sla))
+	if doc.Republished != 1 || doc.Status != StatusPending || doc.Processing != nil {
+		t.Fatalf("status %s, republished %d, processing %v; want it requeued", doc.Status, doc.Republished, doc.Processing)
+	}
+}
+
+func TestSweepPublishes(t *testing.T) {
+	s := NewServer(newTestProducer(t), Options{Sweeper: SweeperPolicy{SLA: time.Minute, MaxRepublish: 3}})
+	id := upload(t, s, context.Background())
+	doc := s.docs[id]
+	if n := countEvents(doc, EventPublished); n != 1 {
+		t.Fatalf("%d published events after upload, want 1", n)
+	}
+
+	s.sweep(context.Background(), doc.QueuedAt.Add(time.Hour))
+	if n := countEvents(doc, EventPublished); n != 2 {
+		t.Errorf("%d published events after sweep, want 2", n)
+	}
+	if doc.PublishError != "" {
+		t.Errorf("publish error %q", doc.PublishError)
+	}
+}
+
+func TestSweeperDisabled(t *testing.T) {
+	s := NewServer(nil, Options{})
+	done := make(chan struct{})
+	go func() {
+		s.RunSweeper(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RunSweeper did not return with a zero SLA")
+	}
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/auth"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/pb"
+)
+
+// Timeline event types.
+const (
+	EventUploaded       = "uploaded"
+	EventPublished      = "published"
+	EventPublishFailed  = "publish_failed"
+	EventPickedUp       = "picked_up"
+	EventNLPAttempt     = "nlp_attempt"
+	EventResultsStored  = "results_stored"
+	EventReviewClaimed  = "review_claimed"
+	EventReviewReleased = "review_released"
+	EventReviewed       = "reviewed"
+	EventReprocessed    = "reprocessed"
+	EventRequeued       = "requeued"
+	EventReplayed       = "replayed"
+	EventRepublished    = "republished"
+	EventTimedOut       = "timed_out"
+)
+
+// consumerEvents are the event types the consumer may report with its
+// results; everything else is recorded by the grpc-service itself.
+var consumerEvents = map[string]bool{
+	EventPickedUp:   true,
+	EventNLPAttempt: true,
+}
+
+// maxTimelineEvents bounds a document's timeline. Once it is full the
+// oldest events after the upload are dropped.
+const maxTimelineEvents = 200
+
+// timelineTimeFormat is RFC 3339 with milliseconds.
+const timelineTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// Event is one entry in a document's timeline.
+type Event struct {
+	Type   string
+	At     time.Time
+	Actor  string
+	Detail map[string]string
+}
+
+// record appends an event to doc's timeline. Callers must hold s.mu for
+// writing.
+func record(doc *Document, typ, actor string, detail map[string]string) {
+	addEvent(doc, Event{Type: typ, At: time.Now().UTC(), Actor: actor, Detail: detail})
+}
+
+func addEvent(doc *Document, e Event) {
+	doc.Timeline = append(doc.Timeline, e)
+	if n := len(doc.Timeline); n > maxTimelineEvents {
+		doc.Timeline = append(doc.Timeline[:1], doc.Timeline[n-maxTimelineEvents+1:]...)
+	}
+}
+
+// recordConsumerEvents adds the events the consumer reported with its
+// results, attributed to worker. Events of unknown types or with a bad
+// timestamp are skipped. Callers must hold s.mu for writing.
+func recordConsumerEvents(doc *Document, worker string, events []*pb.TimelineEvent) {
+	for _, e := range events {
+		if e == nil || !consumerEvents[e.Type] {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339Nano, e.At)
+		if err != nil {
+			continue
+		}
+		detail := make(map[string]string, len(e.Detail))
+		for k, v := range e.Detail {
+			detail[k] = v
+		}
+		addEvent(doc, Event{Type: e.Type, At: at.UTC(), Actor: worker, Detail: detail})
+	}
+}
+
+// actorFrom names the caller in ctx for the timeline.
+func actorFrom(ctx context.Context) string {
+	if id, ok := auth.FromContext(ctx); ok {
+		return id.Subject
+	}
+	return "anonymous"
+}
+
+// recordPublish notes the outcome of publishing doc to Kafka, keeping
+// doc.PublishError for the outbox.
+func (s *Server) recordPublish(doc *Document, partition int32, offset int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		record(doc, EventPublishFailed, "grpc-service", map[string]string{"error": err.Error()})
+		doc.PublishError, doc.PublishFailedAt = err.Error(), time.Now()
+		return
+	}
+	doc.PublishError, doc.PublishFailedAt = "", time.Time{}
+	record(doc, EventPublished, "grpc-service", map[string]string{
+		"partition": strconv.Itoa(int(partition)),
+		"offset":    strconv.FormatInt(offset, 10),
+	})
+}
+
+// ---------------------------------------------------------------------------
+// gRPC service implementation
+// ---------------------------------------------------------------------------
+
+// GetTimeline returns a document's events, oldest first. Consumer events
+// carry the consumer's clock, so they are ordered by time rather than by
+// arrival.
+func (s *Server) GetTimeline(ctx context.Context, req *pb.GetTimelineRequest) (*pb.GetTimelineResponse, error) {
+	if err := s.authorize(ctx, "GetTimeline"); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	doc, err := s.lookup(ctx, req.DocumentId)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := append([]Event(nil), doc.Timeline...)
+	sort.SliceStable(timeline, func(i, j int) bool { return timeline[i].At.Before(timeline[j].At) })
+	events := make([]*pb.TimelineEvent, 0, len(timeline))
+	for _, e := range timeline {
+		pe := &pb.TimelineEvent{Type: e.Type, At: e.At.Format(timelineTimeFormat), Actor: e.Actor}
+		if len(e.Detail) > 0 {
+			pe.Detail = make(map[string]string, len(e.Detail))
+			for k, v := range e.Detail {
+				pe.Detail[k] = v
+			}
+		}
+		events = append(events, pe)
+	}
+	return &pb.GetTimelineResponse{DocumentId: doc.ID, Status: doc.Status, Events: events}, nil
+}
+
+// ---------------------------------------------------------------------------
+// HTTP REST handlers
+// ---------------------------------------------------------------------------
+
+// GET /documents/{id}/timeline — a document's processing history
+func (s *Server) handleGetTimeline(w http.ResponseWriter, r *http.Request) {
+	resp, err := s.GetTimeline(r.Context(), &pb.GetTimelineRequest{DocumentId: r.PathValue("id")})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
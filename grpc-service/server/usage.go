@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/auth"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/pb"
+	"github.com/ryan-dayrit/nlp-pdf-extractor/grpc-service/usage"
+)
+
+// charge meters a document entering the pipeline for tenant, refusing it
+// with ResourceExhausted when it would break the tenant's monthly quota.
+func (s *Server) charge(tenant string, bytes int) error {
+	var quota usage.Quota
+	if s.quotas != nil {
+		quota = s.quotas.For(tenant)
+	}
+	if err := s.usage.Charge(tenant, quota, int64(bytes)); err != nil {
+		return status.Errorf(codes.ResourceExhausted, "tenant %s: %v", tenant, err)
+	}
+	return nil
+}
+
+func checkDayRange(from, to string) error {
+	for _, d := range []string{from, to} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse(usage.DayLayout, d); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid day %q: want YYYY-MM-DD", d)
+		}
+	}
+	return nil
+}
+
+func usageRecordsToPB(records []usage.Record) []*pb.UsageRecord {
+	out := make([]*pb.UsageRecord, 0, len(records))
+	for _, r := range records {
+		out = append(out, usageRecordToPB(r))
+	}
+	return out
+}
+
+func usageRecordToPB(r usage.Record) *pb.UsageRecord {
+	return &pb.UsageRecord{
+		TenantId:  r.Tenant,
+		Day:       r.Day,
+		Documents: r.Documents,
+		Pages:     r.Pages,
+		Bytes:     r.Bytes,
+	}
+}
+
+// ---------------------------------------------------------------------------
+// gRPC service implementation
+// ---------------------------------------------------------------------------
+
+// GetUsage returns the caller's tenant's daily usage, month-to-date total
+// and quota.
+func (s *Server) GetUsage(ctx context.Context, req *pb.GetUsageRequest) (*pb.GetUsageResponse, error) {
+	if err := s.authorize(ctx, "GetUsage"); err != nil {
+		return nil, err
+	}
+	if err := checkDayRange(req.From, req.To); err != nil {
+		return nil, err
+	}
+
+	tenant := auth.TenantFromContext(ctx)
+	resp := &pb.GetUsageResponse{
+		TenantId:    tenant,
+		Records:     usageRecordsToPB(s.usage.Records(tenant, req.From, req.To)),
+		MonthToDate: usageRecordToPB(s.usage.MonthToDate(tenant)),
+		Quota:       &pb.UsageQuota{},
+	}
+	if s.quotas != nil {
+		q := s.quotas.For(tenant)
+		resp.Quota = &pb.UsageQuota{Documents: q.Documents, Pages: q.Pages, Bytes: q.Bytes}
+	}
+	return resp, nil
+}
+
+// ExportUsage returns daily usage of every tenant for chargeback. Because it
+// crosses tenants it is refused unless an access policy grants it.
+func (s *Server) ExportUsage(ctx context.Context, req *pb.ExportUsageRequest) (*pb.ExportUsageResponse, error) {
+	if err := s.authorizeCrossTenant(ctx, "ExportUsage"); err != nil {
+		return nil, err
+	}
+	if err := checkDayRange(req.From, req.To); err != nil {
+		return nil, err
+	}
+	return &pb.ExportUsageResponse{Records: usageRecordsToPB(s.usage.Records("", req.From, req.To))}, nil
+}
+
+// ---------------------------------------------------------------------------
+// HTTP handlers
+// ---------------------------------------------------------------------------
+
+// GET /usage?from=YYYY-MM-DD&to=YYYY-MM-DD — the caller's tenant's usage
+func (s *Server) handleGetUsage(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	resp, err := s.GetUsage(r.Context(), &pb.GetUsageRequest{From: q.Get("from"), To: q.Get("to")})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// GET /usage/export?from=&to=&format=csv|json — every tenant's daily usage
+func (s *Server) handleExportUsage(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		http.Error(w, "format must be csv or json", http.StatusBadRequest)
+		return
+	}
+
+	resp, err := s.ExportUsage(r.Context(), &pb.ExportUsageRequest{From: q.Get("from"), To: q.Get("to")})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if format == "json" {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="usage.csv"`)
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"tenant_id", "day", "documents", "pages", "bytes"})
+	for _, rec := range resp.Records {
+		_ = cw.Write([]string{
+			rec.TenantId,
+			rec.Day,
+			strconv.FormatInt(rec.Documents, 10),
+			strconv.FormatInt(rec.Pages, 10),
+			strconv.FormatInt(rec.Bytes, 10),
+		})
+	}
+	cw.Flush()
+}
@@ -0,0 +1,170 @@
+// Package tlsutil builds server TLS configurations whose certificate and
+// client CA bundle are reloaded from disk when the files change, so
+// certificates can be rotated without a restart.
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// ClientAuth modes accepted by Config.ClientAuth.
+const (
+	ClientAuthNone     = "none"
+	ClientAuthOptional = "optional"
+	ClientAuthRequire  = "require"
+)
+
+// checkInterval limits how often the files are stat'ed for changes.
+const checkInterval = 5 * time.Second
+
+// Config names the files making up a server's TLS identity.
+type Config struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile holds the CAs trusted to sign client certificates.
+	ClientCAFile string
+	// ClientAuth is ClientAuthNone, ClientAuthOptional or ClientAuthRequire;
+	// empty means ClientAuthRequire when ClientCAFile is set and
+	// ClientAuthNone otherwise.
+	ClientAuth string
+}
+
+// Reloader serves the current certificate and client CA pool, re-reading
+// them when a file's modification time changes.
+type Reloader struct {
+	cfg        Config
+	clientAuth tls.ClientAuthType
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTimes  map[string]time.Time
+	checked   time.Time
+}
+
+// NewReloader loads cfg's files. It fails if they cannot be loaded now;
+// later reload failures keep the previous material and are logged.
+func NewReloader(cfg Config) (*Reloader, error) {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return nil, fmt.Errorf("tlsutil: certificate and key files are required")
+	}
+	r := &Reloader{cfg: cfg}
+	mode := cfg.ClientAuth
+	if mode == "" {
+		mode = ClientAuthNone
+		if cfg.ClientCAFile != "" {
+			mode = ClientAuthRequire
+		}
+	}
+	switch mode {
+	case ClientAuthNone:
+		r.clientAuth = tls.NoClientCert
+	case ClientAuthOptional:
+		r.clientAuth = tls.VerifyClientCertIfGiven
+	case ClientAuthRequire:
+		r.clientAuth = tls.RequireAndVerifyClientCert
+	default:
+		return nil, fmt.Errorf("tlsutil: unknown client auth mode %q", mode)
+	}
+	if r.clientAuth != tls.NoClientCert && cfg.ClientCAFile == "" {
+		return nil, fmt.Errorf("tlsutil: client auth %q needs a client CA file", mode)
+	}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Reloader) files() []string {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+	return files
+}
+
+// load reads every file unconditionally.
+func (r *Reloader) load() error {
+	modTimes := make(map[string]time.Time)
+	for _, f := range r.files() {
+		fi, err := os.Stat(f)
+		if err != nil {
+			return fmt.Errorf("tlsutil: %w", err)
+		}
+		modTimes[f] = fi.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("tlsutil: load key pair: %w", err)
+	}
+	var pool *x509.CertPool
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("tlsutil: read client CA: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("tlsutil: no certificates in %s", r.cfg.ClientCAFile)
+		}
+	}
+
+	r.mu.Lock()
+	r.cert, r.clientCAs, r.modTimes = &cert, pool, modTimes
+	r.mu.Unlock()
+	return nil
+}
+
+// maybeReload reloads when any file changed since the last load, checking
+// at most once per checkInterval.
+func (r *Reloader) maybeReload() {
+	r.mu.Lock()
+	if time.Since(r.checked) < checkInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.checked = time.Now()
+	changed := false
+	for _, f := range r.files() {
+		if fi, err := os.Stat(f); err == nil && !fi.ModTime().Equal(r.modTimes[f]) {
+			changed = true
+		}
+	}
+	r.mu.Unlock()
+
+	if changed {
+		if err := r.load(); err != nil {
+			slog.Error("tlsutil: reload failed, keeping previous certificate", "path", r.cfg.CertFile, "error", err)
+			return
+		}
+		slog.Info("tlsutil: reloaded certificate", "path", r.cfg.CertFile)
+	}
+}
+
+// ServerConfig returns a TLS configuration that picks up reloaded material
+// on every handshake. nextProtos sets ALPN, e.g. "h2" for gRPC.
+func (r *Reloader) ServerConfig(nextProtos ...string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: nextProtos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.maybeReload()
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				NextProtos:   nextProtos,
+				Certificates: []tls.Certificate{*r.cert},
+				ClientAuth:   r.clientAuth,
+				ClientCAs:    r.clientCAs,
+			}, nil
+		},
+	}
+}
@@ -0,0 +1,177 @@
+// Package usage meters extraction work per tenant and enforces monthly
+// quotas.
+//
+// Usage is counted per tenant per UTC day: documents and bytes when a
+// document enters the pipeline (upload or reprocess), pages when its results
+// come back, since only the NLP service knows the page count.
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DayLayout formats the Day of a Record.
+const DayLayout = "2006-01-02"
+
+// Record is one tenant's usage on one day, or a total over several days.
+type Record struct {
+	Tenant    string `json:"tenant_id"`
+	Day       string `json:"day,omitempty"`
+	Documents int64  `json:"documents"`
+	Pages     int64  `json:"pages"`
+	Bytes     int64  `json:"bytes"`
+}
+
+type dayKey struct {
+	tenant string
+	day    string
+}
+
+// Meter accumulates usage in memory.
+type Meter struct {
+	mu   sync.RWMutex
+	days map[dayKey]*Record
+	now  func() time.Time
+}
+
+// NewMeter returns an empty Meter.
+func NewMeter() *Meter {
+	return &Meter{days: make(map[dayKey]*Record), now: time.Now}
+}
+
+// Add counts work for tenant on the current day.
+func (m *Meter) Add(tenant string, documents, pages, bytes int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.add(tenant, documents, pages, bytes)
+}
+
+// add implements Add. Callers must hold m.mu.
+func (m *Meter) add(tenant string, documents, pages, bytes int64) {
+	day := m.now().UTC().Format(DayLayout)
+	k := dayKey{tenant, day}
+	r, ok := m.days[k]
+	if !ok {
+		r = &Record{Tenant: tenant, Day: day}
+		m.days[k] = r
+	}
+	r.Documents += documents
+	r.Pages += pages
+	r.Bytes += bytes
+}
+
+// Records returns daily records from from to to inclusive, ordered by tenant
+// then day. An empty tenant selects every tenant; empty bounds are open.
+func (m *Meter) Records(tenant, from, to string) []Record {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var out []Record
+	for k, r := range m.days {
+		if tenant != "" && k.tenant != tenant {
+			continue
+		}
+		if (from != "" && k.day < from) || (to != "" && k.day > to) {
+			continue
+		}
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Tenant != out[j].Tenant {
+			return out[i].Tenant < out[j].Tenant
+		}
+		return out[i].Day < out[j].Day
+	})
+	return out
+}
+
+// MonthToDate returns tenant's total for the current UTC month.
+func (m *Meter) MonthToDate(tenant string) Record {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.monthToDate(tenant)
+}
+
+// Charge counts a document of the given size for tenant unless that would
+// break quota, in which case it returns the reason and counts nothing.
+func (m *Meter) Charge(tenant string, quota Quota, bytes int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := quota.Check(m.monthToDate(tenant), 1, bytes); err != nil {
+		return err
+	}
+	m.add(tenant, 1, 0, bytes)
+	return nil
+}
+
+// monthToDate sums tenant's records for the current UTC month. Callers must
+// hold m.mu.
+func (m *Meter) monthToDate(tenant string) Record {
+	now := m.now().UTC()
+	prefix := now.Format("2006-01-")
+	total := Record{Tenant: tenant}
+	for k, r := range m.days {
+		if k.tenant == tenant && strings.HasPrefix(k.day, prefix) {
+			total.Documents += r.Documents
+			total.Pages += r.Pages
+			total.Bytes += r.Bytes
+		}
+	}
+	return total
+}
+
+// Quota caps a tenant's monthly usage. Zero fields are unlimited.
+type Quota struct {
+	Documents int64 `json:"documents"`
+	Pages     int64 `json:"pages"`
+	Bytes     int64 `json:"bytes"`
+}
+
+// Check reports which limit would be exceeded by adding documents and bytes
+// to used. Pages are only known after processing, so the page quota refuses
+// new work once it has been reached.
+func (q Quota) Check(used Record, documents, bytes int64) error {
+	switch {
+	case q.Documents > 0 && used.Documents+documents > q.Documents:
+		return fmt.Errorf("monthly document quota of %d reached", q.Documents)
+	case q.Bytes > 0 && used.Bytes+bytes > q.Bytes:
+		return fmt.Errorf("monthly byte quota of %d would be exceeded", q.Bytes)
+	case q.Pages > 0 && used.Pages >= q.Pages:
+		return fmt.Errorf("monthly page quota of %d reached", q.Pages)
+	}
+	return nil
+}
+
+// Quotas assigns a Quota to every tenant.
+type Quotas struct {
+	Default Quota            `json:"default"`
+	Tenants map[string]Quota `json:"tenants"`
+}
+
+// LoadQuotas reads a JSON file of the form
+// {"default": {"documents": 1000}, "tenants": {"ops": {"pages": 50000}}}.
+func LoadQuotas(path string) (*Quotas, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("usage: read quotas: %w", err)
+	}
+	var q Quotas
+	if err := json.Unmarshal(data, &q); err != nil {
+		return nil, fmt.Errorf("usage: parse quotas: %w", err)
+	}
+	return &q, nil
+}
+
+// For returns tenant's quota: its own entry, else the default.
+func (q *Quotas) For(tenant string) Quota {
+	if t, ok := q.Tenants[tenant]; ok {
+		return t
+	}
+	return q.Default
+}
@@ -0,0 +1,180 @@
+package usage
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuotaCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		quota     Quota
+		used      Record
+		documents int64
+		bytes     int64
+		wantErr   string // empty when allowed
+	}{
+		{"unlimited", Quota{}, Record{Documents: 1e9, Pages: 1e9, Bytes: 1e12}, 1, 1 << 30, ""},
+
+		{"documents below limit", Quota{Documents: 10}, Record{Documents: 8}, 1, 0, ""},
+		{"documents reach limit", Quota{Documents: 10}, Record{Documents: 9}, 1, 0, ""},
+		{"documents over limit", Quota{Documents: 10}, Record{Documents: 10}, 1, 0, "document quota of 10 reached"},
+
+		{"bytes reach limit", Quota{Bytes: 1000}, Record{Bytes: 600}, 1, 400, ""},
+		{"bytes over limit by one", Quota{Bytes: 1000}, Record{Bytes: 600}, 1, 401, "byte quota of 1000 would be exceeded"},
+		{"single document over limit", Quota{Bytes: 1000}, Record{}, 1, 1001, "byte quota of 1000 would be exceeded"},
+
+		{"pages below limit", Quota{Pages: 100}, Record{Pages: 99}, 1, 0, ""},
+		{"pages at limit", Quota{Pages: 100}, Record{Pages: 100}, 1, 0, "page quota of 100 reached"},
+		{"pages past limit", Quota{Pages: 100}, Record{Pages: 130}, 1, 0, "page quota of 100 reached"},
+
+		{"documents checked first", Quota{Documents: 1, Bytes: 1, Pages: 1}, Record{Documents: 1, Bytes: 1, Pages: 1}, 1, 1, "document quota"},
+		{"other limits unaffected", Quota{Documents: 10, Bytes: 1000, Pages: 100}, Record{Documents: 9, Bytes: 999, Pages: 99}, 1, 1, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.quota.Check(tt.used, tt.documents, tt.bytes)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("err = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// newTestMeter returns a Meter whose clock is *now.
+func newTestMeter(now *time.Time) *Meter {
+	m := NewMeter()
+	m.now = func() time.Time { return *now }
+	return m
+}
+
+func TestCharge(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	m := newTestMeter(&now)
+	quota := Quota{Documents: 3, Bytes: 1000}
+
+	steps := []struct {
+		name    string
+		bytes   int64
+		wantErr bool
+		want    Record // month to date after the call
+	}{
+		{"first", 400, false, Record{Tenant: "acme", Documents: 1, Bytes: 400}},
+		{"over bytes is not counted", 700, true, Record{Tenant: "acme", Documents: 1, Bytes: 400}},
+		{"second", 500, false, Record{Tenant: "acme", Documents: 2, Bytes: 900}},
+		{"third fills the byte quota", 100, false, Record{Tenant: "acme", Documents: 3, Bytes: 1000}},
+		{"over documents is not counted", 0, true, Record{Tenant: "acme", Documents: 3, Bytes: 1000}},
+	}
+	for _, s := range steps {
+		err := m.Charge("acme", quota, s.bytes)
+		if (err != nil) != s.wantErr {
+			t.Fatalf("%s: err = %v, wantErr %v", s.name, err, s.wantErr)
+		}
+		if got := m.MonthToDate("acme"); got != s.want {
+			t.Fatalf("%s: month to date = %+v, want %+v", s.name, got, s.want)
+		}
+	}
+	if got := m.MonthToDate("globex"); got != (Record{Tenant: "globex"}) {
+		t.Errorf("other tenant charged: %+v", got)
+	}
+
+	// A new month starts from zero.
+	now = time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)
+	if err := m.Charge("acme", quota, 100); err != nil {
+		t.Fatalf("new month: err = %v", err)
+	}
+	if got := m.MonthToDate("acme"); got != (Record{Tenant: "acme", Documents: 1, Bytes: 100}) {
+		t.Errorf("new month: month to date = %+v", got)
+	}
+}
+
+func TestChargeCountsPagesAgainstQuota(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	m := newTestMeter(&now)
+	quota := Quota{Pages: 10}
+
+	if err := m.Charge("acme", quota, 0); err != nil {
+		t.Fatal(err)
+	}
+	m.Add("acme", 0, 10, 0)
+	if err := m.Charge("acme", quota, 0); err == nil {
+		t.Fatal("charge after the page quota was reached was allowed")
+	}
+	if got := m.MonthToDate("acme").Documents; got != 1 {
+		t.Errorf("documents = %d, want 1", got)
+	}
+}
+
+func TestRecords(t *testing.T) {
+	now := time.Date(2026, 10, 17, 23, 0, 0, 0, time.UTC)
+	m := newTestMeter(&now)
+	m.Add("globex", 1, 2, 3)
+	m.Add("acme", 1, 0, 100)
+	now = now.Add(2 * time.Hour)
+	m.Add("acme", 2, 5, 200)
+	m.Add("acme", 0, 7, 0)
+
+	tests := []struct {
+		name             string
+		tenant, from, to string
+		want             []Record
+	}{
+		{"all", "", "", "", []Record{
+			{Tenant: "acme", Day: "2026-10-17", Documents: 1, Bytes: 100},
+			{Tenant: "acme", Day: "2026-10-18", Documents: 2, Pages: 12, Bytes: 200},
+			{Tenant: "globex", Day: "2026-10-17", Documents: 1, Pages: 2, Bytes: 3},
+		}},
+		{"tenant", "acme", "", "", []Record{
+			{Tenant: "acme", Day: "2026-10-17", Documents: 1, Bytes: 100},
+			{Tenant: "acme", Day: "2026-10-18", Documents: 2, Pages: 12, Bytes: 200},
+		}},
+		{"from", "", "2026-10-18", "", []Record{
+			{Tenant: "acme", Day: "2026-10-18", Documents: 2, Pages: 12, Bytes: 200},
+		}},
+		{"to", "acme", "", "2026-10-17", []Record{
+			{Tenant: "acme", Day: "2026-10-17", Documents: 1, Bytes: 100},
+		}},
+		{"none", "initech", "", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Records(tt.tenant, tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Records = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuotasFor(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quotas.json")
+	data := `{"default": {"documents": 1000}, "tenants": {"ops": {"pages": 50000}}}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	q, err := LoadQuotas(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		tenant string
+		want   Quota
+	}{
+		{"ops", Quota{Pages: 50000}},
+		{"acme", Quota{Documents: 1000}},
+	}
+	for _, tt := range tests {
+		if got := q.For(tt.tenant); got != tt.want {
+			t.Errorf("For(%q) = %+v, want %+v", tt.tenant, got, tt.want)
+		}
+	}
+}
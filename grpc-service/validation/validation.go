@@ -0,0 +1,357 @@
+// Package validation checks extracted data point values against rule sets
+// loaded from a JSON file.
+//
+// Rules are attached to data point names; cross-field rules relate several
+// data points of the same document. A rule only applies to documents that
+// requested every data point it references. Names match the way the NLP
+// service reads them, ignoring case and surrounding space.
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rule types.
+const (
+	RuleRequired   = "required"
+	RuleRegex      = "regex"
+	RuleRange      = "range"
+	RuleDateWindow = "date_window"
+	RuleSum        = "sum"
+	RuleDateOrder  = "date_order"
+)
+
+// Rule is a single check. Which fields are used depends on Type.
+type Rule struct {
+	Type string `json:"type"`
+
+	// regex
+	Pattern string `json:"pattern,omitempty"`
+
+	// range
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+
+	// date_window: absolute bounds (YYYY-MM-DD) and/or bounds relative to now.
+	After         string `json:"after,omitempty"`
+	Before        string `json:"before,omitempty"`
+	MaxAgeDays    *int   `json:"max_age_days,omitempty"`
+	MaxFutureDays *int   `json:"max_future_days,omitempty"`
+
+	// sum (cross-field): Terms must add up to Equals within Tolerance.
+	Terms     []string `json:"terms,omitempty"`
+	Equals    string   `json:"equals,omitempty"`
+	Tolerance float64  `json:"tolerance,omitempty"`
+
+	// date_order (cross-field): Earlier must not be after Later.
+	Earlier string `json:"earlier,omitempty"`
+	Later   string `json:"later,omitempty"`
+
+	re                    *regexp.Regexp
+	afterDate, beforeDate time.Time
+}
+
+// RuleSet is the parsed contents of a rules file.
+type RuleSet struct {
+	// DataPoints maps a data point name to the rules for its value.
+	DataPoints map[string][]*Rule `json:"data_points"`
+	// CrossField holds rules spanning several data points.
+	CrossField []*Rule `json:"cross_field"`
+
+	now func() time.Time
+}
+
+// Violation describes a rule an extracted value failed.
+type Violation struct {
+	Rule       string
+	DataPoints []string
+	Message    string
+}
+
+// Load reads and compiles the rule set at path.
+func Load(path string) (*RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("validation: read rules: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse compiles a rule set from its JSON form.
+func Parse(data []byte) (*RuleSet, error) {
+	var rs RuleSet
+	if err := json.Unmarshal(data, &rs); err != nil {
+		return nil, fmt.Errorf("validation: parse rules: %w", err)
+	}
+	for dp, rules := range rs.DataPoints {
+		for _, r := range rules {
+			if err := r.compile(false); err != nil {
+				return nil, fmt.Errorf("validation: data point %q: %w", dp, err)
+			}
+		}
+	}
+	for i, r := range rs.CrossField {
+		if err := r.compile(true); err != nil {
+			return nil, fmt.Errorf("validation: cross_field[%d]: %w", i, err)
+		}
+	}
+	rs.now = time.Now
+	return &rs, nil
+}
+
+func (r *Rule) compile(crossField bool) error {
+	var err error
+	switch r.Type {
+	case RuleRequired, RuleRange:
+	case RuleRegex:
+		if r.re, err = regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("regex: %w", err)
+		}
+	case RuleDateWindow:
+		if r.After != "" {
+			if r.afterDate, err = time.Parse(time.DateOnly, r.After); err != nil {
+				return fmt.Errorf("date_window after: %w", err)
+			}
+		}
+		if r.Before != "" {
+			if r.beforeDate, err = time.Parse(time.DateOnly, r.Before); err != nil {
+				return fmt.Errorf("date_window before: %w", err)
+			}
+		}
+	case RuleSum:
+		if !crossField {
+			return fmt.Errorf("%s is a cross_field rule", r.Type)
+		}
+		if len(r.Terms) == 0 || r.Equals == "" {
+			return fmt.Errorf("sum needs terms and equals")
+		}
+	case RuleDateOrder:
+		if !crossField {
+			return fmt.Errorf("%s is a cross_field rule", r.Type)
+		}
+		if r.Earlier == "" || r.Later == "" {
+			return fmt.Errorf("date_order needs earlier and later")
+		}
+	default:
+		return fmt.Errorf("unknown rule type %q", r.Type)
+	}
+	if crossField && r.Type != RuleSum && r.Type != RuleDateOrder {
+		return fmt.Errorf("%s is not a cross_field rule", r.Type)
+	}
+	return nil
+}
+
+// Validate checks results for a document that requested dataPoints and
+// returns every violation, ordered by data point, a cross-field rule by the
+// first one it references, then by message. A nil RuleSet never reports
+// violations.
+func (rs *RuleSet) Validate(dataPoints []string, results map[string]string) []Violation {
+	if rs == nil {
+		return nil
+	}
+	requested := make(map[string]bool, len(dataPoints))
+	for _, dp := range dataPoints {
+		requested[normalize(dp)] = true
+	}
+	values := make(map[string]string, len(results))
+	for dp, v := range results {
+		values[normalize(dp)] = v
+	}
+
+	var out []Violation
+	for dp, rules := range rs.DataPoints {
+		if !requested[normalize(dp)] {
+			continue
+		}
+		for _, r := range rules {
+			if msg := rs.check(r, values[normalize(dp)]); msg != "" {
+				out = append(out, Violation{Rule: r.Type, DataPoints: []string{dp}, Message: fmt.Sprintf("%s: %s", dp, msg)})
+			}
+		}
+	}
+	for _, r := range rs.CrossField {
+		refs := r.references()
+		if !all(refs, requested) {
+			continue
+		}
+		if msg := checkCrossField(r, values); msg != "" {
+			out = append(out, Violation{Rule: r.Type, DataPoints: refs, Message: msg})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if a, b := out[i].DataPoints[0], out[j].DataPoints[0]; a != b {
+			return a < b
+		}
+		return out[i].Message < out[j].Message
+	})
+	return out
+}
+
+// check returns a description of how value fails r, or "" if it passes.
+// Empty values only fail the required rule; the other rules skip them.
+func (rs *RuleSet) check(r *Rule, value string) string {
+	if strings.TrimSpace(value) == "" {
+		if r.Type == RuleRequired {
+			return "value is required"
+		}
+		return ""
+	}
+	switch r.Type {
+	case RuleRegex:
+		if !r.re.MatchString(value) {
+			return fmt.Sprintf("%q does not match %s", value, r.Pattern)
+		}
+	case RuleRange:
+		n, ok := ParseNumber(value)
+		if !ok {
+			return fmt.Sprintf("%q is not a number", value)
+		}
+		if r.Min != nil && n < *r.Min {
+			return fmt.Sprintf("%g is below minimum %g", n, *r.Min)
+		}
+		if r.Max != nil && n > *r.Max {
+			return fmt.Sprintf("%g is above maximum %g", n, *r.Max)
+		}
+	case RuleDateWindow:
+		d, ok := ParseDate(value)
+		if !ok {
+			return fmt.Sprintf("%q is not a date", value)
+		}
+		today := rs.now().UTC().Truncate(24 * time.Hour)
+		if !r.afterDate.IsZero() && d.Before(r.afterDate) {
+			return fmt.Sprintf("%s is before %s", d.Format(time.DateOnly), r.After)
+		}
+		if !r.beforeDate.IsZero() && d.After(r.beforeDate) {
+			return fmt.Sprintf("%s is after %s", d.Format(time.DateOnly), r.Before)
+		}
+		if r.MaxAgeDays != nil && d.Before(today.AddDate(0, 0, -*r.MaxAgeDays)) {
+			return fmt.Sprintf("%s is more than %d days old", d.Format(time.DateOnly), *r.MaxAgeDays)
+		}
+		if r.MaxFutureDays != nil && d.After(today.AddDate(0, 0, *r.MaxFutureDays)) {
+			return fmt.Sprintf("%s is more than %d days in the future", d.Format(time.DateOnly), *r.MaxFutureDays)
+		}
+	}
+	return ""
+}
+
+// checkCrossField returns a description of how values, keyed by normalized
+// data point name, fail r, or "" if they pass. Like the per-value rules it
+// skips empty values, which only the required rule reports.
+func checkCrossField(r *Rule, values map[string]string) string {
+	for _, ref := range r.references() {
+		if strings.TrimSpace(values[normalize(ref)]) == "" {
+			return ""
+		}
+	}
+	switch r.Type {
+	case RuleSum:
+		var sum float64
+		for _, t := range r.Terms {
+			v := values[normalize(t)]
+			n, ok := ParseNumber(v)
+			if !ok {
+				return fmt.Sprintf("%s: %q is not a number", t, v)
+			}
+			sum += n
+		}
+		want, ok := ParseNumber(values[normalize(r.Equals)])
+		if !ok {
+			return fmt.Sprintf("%s: %q is not a number", r.Equals, values[normalize(r.Equals)])
+		}
+		tolerance := r.Tolerance
+		if tolerance == 0 {
+			tolerance = 0.005
+		}
+		if math.Abs(sum-want) > tolerance {
+			return fmt.Sprintf("%s = %g, want %s = %g", strings.Join(r.Terms, " + "), sum, r.Equals, want)
+		}
+	case RuleDateOrder:
+		earlier, ok1 := ParseDate(values[normalize(r.Earlier)])
+		later, ok2 := ParseDate(values[normalize(r.Later)])
+		if !ok1 || !ok2 {
+			return fmt.Sprintf("%s and %s must both be dates", r.Earlier, r.Later)
+		}
+		if earlier.After(later) {
+			return fmt.Sprintf("%s (%s) is after %s (%s)", r.Earlier, earlier.Format(time.DateOnly), r.Later, later.Format(time.DateOnly))
+		}
+	}
+	return ""
+}
+
+func (r *Rule) references() []string {
+	switch r.Type {
+	case RuleSum:
+		return append(append([]string(nil), r.Terms...), r.Equals)
+	case RuleDateOrder:
+		return []string{r.Earlier, r.Later}
+	}
+	return nil
+}
+
+func all(names []string, set map[string]bool) bool {
+	for _, n := range names {
+		if !set[normalize(n)] {
+			return false
+		}
+	}
+	return true
+}
+
+// normalize returns the form of a data point name used to match it, the
+// same one the NLP service dispatches on.
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// numberRE is an amount with optional comma thousands separators and a
+// point as the decimal separator.
+var numberRE = regexp.MustCompile(`^-?(?:\d{1,3}(?:,\d{3})+|\d+)(?:\.\d+)?$`)
+
+// ParseNumber reads an amount such as "$1,234.56", "1,234.56 USD" or "-42",
+// ignoring currency symbols and codes. Amounts written with a decimal comma,
+// such as "1.234,56", are rejected rather than misread.
+func ParseNumber(s string) (float64, bool) {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9', r == '.', r == ',', r == '-':
+			b.WriteRune(r)
+		}
+	}
+	if !numberRE.MatchString(b.String()) {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(strings.ReplaceAll(b.String(), ",", ""), 64)
+	return n, err == nil
+}
+
+// dateLayouts mirrors the date formats the NLP service recognises.
+var dateLayouts = []string{
+	"2006-01-02",
+	"1/2/2006",
+	"1/2/06",
+	"January 2, 2006",
+	"January 2 2006",
+	"2 January 2006",
+	"2-Jan-2006",
+	"2-Jan-06",
+}
+
+// ParseDate reads a date in any of the formats the NLP service extracts.
+func ParseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
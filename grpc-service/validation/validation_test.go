@@ -0,0 +1,232 @@
+package validation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// today is the clock for date_window rules.
+var today = time.Date(2026, 10, 18, 15, 4, 5, 0, time.UTC)
+
+func mustParse(t *testing.T, rules string) *RuleSet {
+	t.Helper()
+	rs, err := Parse([]byte(rules))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	rs.now = func() time.Time { return today }
+	return rs
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		results map[string]string
+		want    string // the single violation's message; empty for none
+	}{
+		{"required present", `{"data_points": {"total": [{"type": "required"}]}}`,
+			map[string]string{"total": "12"}, ""},
+		{"required missing", `{"data_points": {"total": [{"type": "required"}]}}`,
+			map[string]string{}, "total: value is required"},
+		{"required blank", `{"data_points": {"total": [{"type": "required"}]}}`,
+			map[string]string{"total": "  "}, "total: value is required"},
+
+		{"regex match", `{"data_points": {"invoice_number": [{"type": "regex", "pattern": "^INV-\\d+$"}]}}`,
+			map[string]string{"invoice_number": "INV-42"}, ""},
+		{"regex mismatch", `{"data_points": {"invoice_number": [{"type": "regex", "pattern": "^INV-\\d+$"}]}}`,
+			map[string]string{"invoice_number": "42"}, `invoice_number: "42" does not match ^INV-\d+$`},
+		{"regex skips empty", `{"data_points": {"invoice_number": [{"type": "regex", "pattern": "^INV-\\d+$"}]}}`,
+			map[string]string{}, ""},
+
+		{"range inside", `{"data_points": {"total": [{"type": "range", "min": 0, "max": 1000}]}}`,
+			map[string]string{"total": "$999.99"}, ""},
+		{"range at bounds", `{"data_points": {"total": [{"type": "range", "min": 0, "max": 1000}]}}`,
+			map[string]string{"total": "1,000.00 USD"}, ""},
+		{"range below", `{"data_points": {"total": [{"type": "range", "min": 0}]}}`,
+			map[string]string{"total": "-5"}, "total: -5 is below minimum 0"},
+		{"range above", `{"data_points": {"total": [{"type": "range", "max": 1000}]}}`,
+			map[string]string{"total": "$1,000.01"}, "total: 1000.01 is above maximum 1000"},
+		{"range not a number", `{"data_points": {"total": [{"type": "range", "min": 0}]}}`,
+			map[string]string{"total": "n/a"}, `total: "n/a" is not a number`},
+
+		{"date_window inside", `{"data_points": {"due_date": [{"type": "date_window", "after": "2026-01-01", "before": "2026-12-31"}]}}`,
+			map[string]string{"due_date": "October 1, 2026"}, ""},
+		{"date_window before after", `{"data_points": {"due_date": [{"type": "date_window", "after": "2026-01-01"}]}}`,
+			map[string]string{"due_date": "12/31/2025"}, "due_date: 2025-12-31 is before 2026-01-01"},
+		{"date_window after before", `{"data_points": {"due_date": [{"type": "date_window", "before": "2026-12-31"}]}}`,
+			map[string]string{"due_date": "2027-01-01"}, "due_date: 2027-01-01 is after 2026-12-31"},
+		{"date_window max age", `{"data_points": {"invoice_date": [{"type": "date_window", "max_age_days": 30}]}}`,
+			map[string]string{"invoice_date": "2026-09-17"}, "invoice_date: 2026-09-17 is more than 30 days old"},
+		{"date_window max age boundary", `{"data_points": {"invoice_date": [{"type": "date_window", "max_age_days": 30}]}}`,
+			map[string]string{"invoice_date": "2026-09-18"}, ""},
+		{"date_window max future", `{"data_points": {"invoice_date": [{"type": "date_window", "max_future_days": 0}]}}`,
+			map[string]string{"invoice_date": "2026-10-19"}, "invoice_date: 2026-10-19 is more than 0 days in the future"},
+		{"date_window today", `{"data_points": {"invoice_date": [{"type": "date_window", "max_future_days": 0}]}}`,
+			map[string]string{"invoice_date": "18 October 2026"}, ""},
+		{"date_window not a date", `{"data_points": {"invoice_date": [{"type": "date_window", "max_age_days": 30}]}}`,
+			map[string]string{"invoice_date": "soon"}, `invoice_date: "soon" is not a date`},
+
+		{"sum matches", `{"cross_field": [{"type": "sum", "terms": ["subtotal", "tax"], "equals": "total"}]}`,
+			map[string]string{"subtotal": "$100.00", "tax": "$8.25", "total": "$108.25"}, ""},
+		{"sum within tolerance", `{"cross_field": [{"type": "sum", "terms": ["subtotal", "tax"], "equals": "total", "tolerance": 0.05}]}`,
+			map[string]string{"subtotal": "100", "tax": "8.25", "total": "108.29"}, ""},
+		{"sum off", `{"cross_field": [{"type": "sum", "terms": ["subtotal", "tax"], "equals": "total"}]}`,
+			map[string]string{"subtotal": "100", "tax": "8.25", "total": "110"}, "subtotal + tax = 108.25, want total = 110"},
+		{"sum term not a number", `{"cross_field": [{"type": "sum", "terms": ["subtotal", "tax"], "equals": "total"}]}`,
+			map[string]string{"subtotal": "100", "tax": "n/a", "total": "100"}, `tax: "n/a" is not a number`},
+		{"sum skips empty", `{"cross_field": [{"type": "sum", "terms": ["subtotal", "tax"], "equals": "total"}]}`,
+			map[string]string{"subtotal": "100", "tax": " ", "total": "100"}, ""},
+		{"sum decimal comma", `{"cross_field": [{"type": "sum", "terms": ["subtotal", "tax"], "equals": "total"}]}`,
+			map[string]string{"subtotal": "1.234,56", "tax": "0", "total": "1234.56"}, `subtotal: "1.234,56" is not a number`},
+
+		{"date_order in order", `{"cross_field": [{"type": "date_order", "earlier": "invoice_date", "later": "due_date"}]}`,
+			map[string]string{"invoice_date": "2026-10-01", "due_date": "2026-10-31"}, ""},
+		{"date_order same day", `{"cross_field": [{"type": "date_order", "earlier": "invoice_date", "later": "due_date"}]}`,
+			map[string]string{"invoice_date": "2026-10-01", "due_date": "1-Oct-2026"}, ""},
+		{"date_order reversed", `{"cross_field": [{"type": "date_order", "earlier": "invoice_date", "later": "due_date"}]}`,
+			map[string]string{"invoice_date": "2026-10-31", "due_date": "2026-10-01"}, "invoice_date (2026-10-31) is after due_date (2026-10-01)"},
+		{"date_order not dates", `{"cross_field": [{"type": "date_order", "earlier": "invoice_date", "later": "due_date"}]}`,
+			map[string]string{"invoice_date": "2026-10-31", "due_date": "soon"}, "invoice_date and due_date must both be dates"},
+		{"date_order skips empty", `{"cross_field": [{"type": "date_order", "earlier": "invoice_date", "later": "due_date"}]}`,
+			map[string]string{"invoice_date": "2026-10-31"}, ""},
+	}
+	requested := []string{"total", "subtotal", "tax", "invoice_number", "invoice_date", "due_date"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mustParse(t, tt.rules).Validate(requested, tt.results)
+			switch {
+			case tt.want == "" && len(got) != 0:
+				t.Errorf("violations = %+v, want none", got)
+			case tt.want != "" && (len(got) != 1 || got[0].Message != tt.want):
+				t.Errorf("violations = %+v, want one with message %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSkipsUnrequested(t *testing.T) {
+	rs := mustParse(t, `{
+		"data_points": {"total": [{"type": "required"}]},
+		"cross_field": [{"type": "sum", "terms": ["subtotal", "tax"], "equals": "total"}]
+	}`)
+	if got := rs.Validate([]string{"subtotal", "tax"}, map[string]string{"subtotal": "1", "tax": "1"}); len(got) != 0 {
+		t.Errorf("violations = %+v, want none", got)
+	}
+}
+
+func TestValidateOrder(t *testing.T) {
+	rs := mustParse(t, `{
+		"data_points": {
+			"total": [{"type": "required"}, {"type": "range", "min": 0}],
+			"due_date": [{"type": "required"}],
+			"tax": [{"type": "regex", "pattern": "^\\d"}]
+		},
+		"cross_field": [{"type": "sum", "terms": ["subtotal", "tax"], "equals": "total"}]
+	}`)
+	got := rs.Validate([]string{"total", "due_date", "tax", "subtotal"},
+		map[string]string{"total": "-1", "tax": "x1", "subtotal": "3"})
+
+	want := []Violation{
+		{Rule: RuleRequired, DataPoints: []string{"due_date"}, Message: "due_date: value is required"},
+		{Rule: RuleSum, DataPoints: []string{"subtotal", "tax", "total"}, Message: "subtotal + tax = 4, want total = -1"},
+		{Rule: RuleRegex, DataPoints: []string{"tax"}, Message: `tax: "x1" does not match ^\d`},
+		{Rule: RuleRange, DataPoints: []string{"total"}, Message: "total: -1 is below minimum 0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("violations:\n got  %+v\n want %+v", got, want)
+	}
+}
+
+func TestValidateMatchesNamesLikeExtraction(t *testing.T) {
+	rs := mustParse(t, `{
+		"data_points": {"Total": [{"type": "range", "max": 100}]},
+		"cross_field": [{"type": "sum", "terms": ["subtotal", "TAX"], "equals": "total"}]
+	}`)
+	got := rs.Validate([]string{" total", "Subtotal", "tax "},
+		map[string]string{" total": "$150", "Subtotal": "100", "tax ": "10"})
+
+	want := []Violation{
+		{Rule: RuleRange, DataPoints: []string{"Total"}, Message: "Total: 150 is above maximum 100"},
+		{Rule: RuleSum, DataPoints: []string{"subtotal", "TAX", "total"}, Message: "subtotal + TAX = 110, want total = 150"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("violations:\n got  %+v\n want %+v", got, want)
+	}
+}
+
+func TestValidateNilRuleSet(t *testing.T) {
+	var rs *RuleSet
+	if got := rs.Validate([]string{"total"}, nil); got != nil {
+		t.Errorf("violations = %+v, want nil", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		wantErr string
+	}{
+		{"bad json", `{`, "parse rules"},
+		{"unknown type", `{"data_points": {"total": [{"type": "positive"}]}}`, `unknown rule type "positive"`},
+		{"bad regex", `{"data_points": {"total": [{"type": "regex", "pattern": "("}]}}`, "regex:"},
+		{"bad date bound", `{"data_points": {"due_date": [{"type": "date_window", "after": "01/02/2026"}]}}`, "date_window after"},
+		{"sum on a data point", `{"data_points": {"total": [{"type": "sum", "terms": ["a"], "equals": "b"}]}}`, "sum is a cross_field rule"},
+		{"sum without equals", `{"cross_field": [{"type": "sum", "terms": ["a"]}]}`, "sum needs terms and equals"},
+		{"date_order without later", `{"cross_field": [{"type": "date_order", "earlier": "a"}]}`, "date_order needs earlier and later"},
+		{"required across fields", `{"cross_field": [{"type": "required"}]}`, "required is not a cross_field rule"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.rules))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   float64
+		wantOK bool
+	}{
+		{"42", 42, true},
+		{"$1,234.56", 1234.56, true},
+		{"1,234.56 USD", 1234.56, true},
+		{"-42", -42, true},
+		{"€ 7", 7, true},
+		{"n/a", 0, false},
+		{"", 0, false},
+		{"1.2.3", 0, false},
+		{"1,234,567", 1234567, true},
+		{"0.5", 0.5, true},
+		// A decimal comma is ambiguous with a thousands separator.
+		{"1.234,56", 0, false},
+		{"1,234,56", 0, false},
+		{"12,5", 0, false},
+		{"1.234.567", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseNumber(tt.in)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseNumber(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	want := time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC)
+	for _, in := range []string{"2026-03-04", "3/4/2026", "3/4/26", "March 4, 2026", "March 4 2026", "4 March 2026", "4-Mar-2026", "4-Mar-26", " 2026-03-04 "} {
+		if got, ok := ParseDate(in); !ok || !got.Equal(want) {
+			t.Errorf("ParseDate(%q) = %v, %v; want %v", in, got, ok, want)
+		}
+	}
+	if _, ok := ParseDate("04.03.2026"); ok {
+		t.Error("ParseDate accepted an unsupported layout")
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/ryan-dayrit/nlp-pdf-extractor/health
+
+go 1.22
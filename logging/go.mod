@@ -0,0 +1,7 @@
+module github.com/ryan-dayrit/nlp-pdf-extractor/logging
+
+go 1.22
+
+require go.opentelemetry.io/otel/trace v1.24.0
+
+require go.opentelemetry.io/otel v1.24.0 // indirect
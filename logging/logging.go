@@ -0,0 +1,130 @@
+// Package logging configures log/slog for the Go services: JSON or text
+// output, a level that can be changed while running, and records enriched
+// with the request ID, trace ID and attributes carried by their context.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Formats accepted by Config.Format.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Config selects the log format and starting level. It is loaded as a
+// section by package config.
+type Config struct {
+	Format string `key:"format" env:"LOG_FORMAT" usage:"log output: json or text"`
+	Level  string `key:"level" env:"LOG_LEVEL" usage:"minimum level logged at startup: debug, info, warn or error"`
+}
+
+// DefaultConfig logs JSON at info level.
+func DefaultConfig() Config {
+	return Config{Format: FormatJSON, Level: "info"}
+}
+
+// Validate implements config.Validator.
+func (c *Config) Validate() error {
+	if c.Format != FormatJSON && c.Format != FormatText {
+		return fmt.Errorf("format: %q, want %s or %s", c.Format, FormatJSON, FormatText)
+	}
+	if _, err := ParseLevel(c.Level); err != nil {
+		return fmt.Errorf("level: %w", err)
+	}
+	return nil
+}
+
+// ParseLevel parses debug, info, warn or error, ignoring case.
+func ParseLevel(s string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("%q, want debug, info, warn or error", s)
+	}
+	return l, nil
+}
+
+// Setup makes a logger for service the slog default, which also routes the
+// standard log package through it. Records are written to stderr. The
+// returned LevelVar changes the level while running.
+func Setup(service string, cfg Config) (*slog.LevelVar, error) {
+	level := new(slog.LevelVar)
+	l, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+	level.Set(l)
+	slog.SetDefault(New(os.Stderr, cfg.Format, level).With("service", service))
+	return level, nil
+}
+
+// New returns a logger writing format records at level or above to w.
+func New(w io.Writer, format string, level slog.Leveler) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	var h slog.Handler
+	if format == FormatText {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(contextHandler{h})
+}
+
+// attrsKey holds the attributes added by With.
+type attrsKey struct{}
+
+// With returns a copy of ctx whose log records carry args, given as
+// alternating keys and values or slog.Attrs as for slog.Logger.With.
+// Records must be logged with a context-aware call such as
+// slog.InfoContext.
+func With(ctx context.Context, args ...any) context.Context {
+	r := slog.NewRecord(time.Time{}, 0, "", 0)
+	r.Add(args...)
+	attrs := append([]slog.Attr(nil), attrsFrom(ctx)...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return context.WithValue(ctx, attrsKey{}, attrs)
+}
+
+func attrsFrom(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// contextHandler adds request_id, trace_id and the attributes from With to
+// every record logged with a context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := RequestID(ctx); id != "" {
+			r.AddAttrs(slog.String("request_id", id))
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+			r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+		}
+		r.AddAttrs(attrsFrom(ctx)...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -1,5 +1,8 @@
 import re
 import sys
+from bisect import bisect_right
+from dataclasses import dataclass
+
 import fitz  # PyMuPDF
 
 if sys.version_info < (3, 14):
@@ -46,42 +49,75 @@ _COMPANY_LABEL_RE = re.compile(
 )
 
 
-def _extract_text(pdf_bytes: bytes) -> str:
-    """Return concatenated text from every page of the PDF."""
-    doc = fitz.open(stream=pdf_bytes, filetype="pdf")
-    pages = [page.get_text() for page in doc]
-    doc.close()
-    return "\n".join(pages)
+_SNIPPET_RADIUS = 40
+_MAX_CANDIDATES = 5
+
+# Per-data-point result statuses.
+STATUS_FOUND = "found"
+STATUS_NOT_FOUND = "not_found"
+STATUS_AMBIGUOUS = "ambiguous"
+STATUS_ERROR = "error"
+STATUS_SKIPPED = "skipped"
+
+
+@dataclass
+class _Match:
+    """A single candidate value located at [start, end) in the document text."""
+
+    value: str
+    start: int
+    end: int
+    confidence: float
+
+
+def _extract_pages(doc: "fitz.Document") -> list[str]:
+    """Return the text of every page of the PDF, in page order."""
+    return [page.get_text() for page in doc]
 
 
-def _find_dates(text: str) -> list[str]:
-    matches: list[str] = []
+def _find_dates(text: str) -> list[_Match]:
+    matches: list[_Match] = []
     for pattern in _DATE_PATTERNS:
-        matches.extend(re.findall(pattern, text, re.IGNORECASE))
+        for m in re.finditer(pattern, text, re.IGNORECASE):
+            matches.append(_Match(m.group(0), m.start(), m.end(), 0.8))
     return matches
 
 
-def _find_amounts(text: str) -> list[str]:
-    matches: list[str] = []
+def _find_amounts(text: str) -> list[_Match]:
+    matches: list[_Match] = []
     for pattern in _AMOUNT_PATTERNS:
-        matches.extend(re.findall(pattern, text))
+        for m in re.finditer(pattern, text):
+            matches.append(_Match(m.group(0), m.start(), m.end(), 0.8))
     return matches
 
 
-def _find_company_names(text: str) -> list[str]:
+def _first_line(text: str, start: int) -> tuple[str, int, int]:
+    """Return the stripped first line of *text* beginning at *start* with its offsets."""
+    line = text[start:].splitlines()[0] if text[start:] else ""
+    lead = len(line) - len(line.lstrip())
+    value = line.strip()
+    return value, start + lead, start + lead + len(value)
+
+
+def _find_company_names(text: str) -> list[_Match]:
     # 1. Try structured label patterns first.
-    label_matches = _COMPANY_LABEL_RE.findall(text)
-    results = [m.strip().splitlines()[0].strip() for m in label_matches if m.strip()]
+    results: list[_Match] = []
+    for m in _COMPANY_LABEL_RE.finditer(text):
+        value, start, end = _first_line(text, m.start(1))
+        if value:
+            results.append(_Match(value, start, end, 0.85))
 
     # 2. Fall back to spaCy NER ORG entities.
     if not results and _nlp is not None:
         doc = _nlp(text[:100_000])  # cap to avoid memory issues
-        results = [ent.text.strip() for ent in doc.ents if ent.label_ == "ORG"]
+        for ent in doc.ents:
+            if ent.label_ == "ORG":
+                results.append(_Match(ent.text.strip(), ent.start_char, ent.end_char, 0.6))
 
     return results
 
 
-def _find_by_label(text: str, label: str) -> str:
+def _find_by_label(text: str, label: str) -> list[_Match]:
     """Generic extraction: find *label* in text and return the value that follows it."""
     pattern = re.compile(
         r"(?i)" + re.escape(label) + r"\s*[:\-]?\s*(.+)",
@@ -89,39 +125,159 @@ def _find_by_label(text: str, label: str) -> str:
     match = pattern.search(text)
     if match:
         # Return only the first line of whatever follows.
-        return match.group(1).strip().splitlines()[0].strip()
-    return ""
+        value, start, end = _first_line(text, match.start(1))
+        return [_Match(value, start, end, 0.9)]
+    return []
+
+
+def _locate(doc: "fitz.Document", page_starts: list[int], text: str, match: _Match) -> dict:
+    """Describe where *match* sits in the document: page, bounding box and snippet."""
+    page_index = bisect_right(page_starts, match.start) - 1
+    bbox = None
+    if match.value:
+        # search_for returns every occurrence on the page in reading order, so
+        # pick the one that the match offset falls on, not simply the first.
+        page_text = text[page_starts[page_index]:match.start]
+        nth = page_text.count(match.value)
+        rects = doc[page_index].search_for(match.value)
+        if nth < len(rects):
+            r = rects[nth]
+            bbox = {"x0": r.x0, "y0": r.y0, "x1": r.x1, "y1": r.y1}
+
+    snippet_start = max(0, match.start - _SNIPPET_RADIUS)
+    snippet_end = min(len(text), match.end + _SNIPPET_RADIUS)
+    return {
+        "value": match.value,
+        "confidence": match.confidence,
+        "page": page_index + 1,
+        "bbox": bbox,
+        "start_offset": match.start,
+        "end_offset": match.end,
+        "snippet": " ".join(text[snippet_start:snippet_end].split()),
+    }
+
+
+def _rank(found: list[_Match]) -> list[_Match]:
+    """Collapse repeated values and order the remaining candidates best-first.
+
+    The first value the finder produced stays on top: finders try their most
+    specific patterns first, and that value is what extraction has always
+    returned. The rest are ordered by how often they occur. When more than one
+    distinct value exists every confidence is discounted, and no candidate
+    scores higher than the one ranked above it.
+    """
+    counts: dict[str, int] = {}
+    first: dict[str, _Match] = {}
+    for m in found:
+        counts[m.value] = counts.get(m.value, 0) + 1
+        first.setdefault(m.value, m)
+
+    top, *rest = first.values()
+    rest.sort(key=lambda m: -counts[m.value])
+    if not rest:
+        return [top]
+
+    ranked = [_Match(top.value, top.start, top.end, round(top.confidence * 0.75, 2))]
+    for m in rest[: _MAX_CANDIDATES - 1]:
+        share = counts[m.value] / len(found)
+        confidence = min(ranked[-1].confidence, round(m.confidence * 0.5 * (1 + share), 2))
+        ranked.append(_Match(m.value, m.start, m.end, confidence))
+    return ranked
+
+
+def _empty(status: str, reason: str) -> dict:
+    """Result for a data point that produced no value, with *status* explaining why."""
+    return {
+        "value": "",
+        "confidence": 0.0,
+        "page": 0,
+        "bbox": None,
+        "start_offset": 0,
+        "end_offset": 0,
+        "snippet": "",
+        "candidates": [],
+        "status": status,
+        "reason": reason,
+    }
+
+
+def _find(text: str, dp: str) -> list[_Match]:
+    """Dispatch *dp* to the finder that understands it."""
+    key = dp.lower().strip()
+
+    if re.search(r"\bdate\b", key):
+        return _find_dates(text)
+
+    if re.search(r"\b(amount|total|price|cost|sum|balance|due)\b", key):
+        return _find_amounts(text)
+
+    if re.search(r"\b(company|client|vendor|supplier|organization|organisation)\b", key):
+        return _find_company_names(text)
+
+    # Generic: look for the label verbatim in the document.
+    return _find_by_label(text, dp)
 
 
 # ---------------------------------------------------------------------------
 # Public entry point
 # ---------------------------------------------------------------------------
 
-def extract_data_points(pdf_bytes: bytes, data_points: list[str]) -> dict[str, str]:
-    """Extract requested *data_points* from *pdf_bytes* and return a mapping."""
-    text = _extract_text(pdf_bytes)
-    results: dict[str, str] = {}
+def count_pages(pdf_bytes: bytes) -> int:
+    """Return the number of pages in *pdf_bytes*."""
+    doc = fitz.open(stream=pdf_bytes, filetype="pdf")
+    try:
+        return doc.page_count
+    finally:
+        doc.close()
+
 
-    for dp in data_points:
-        key = dp.lower().strip()
-        value = ""
+def extract_data_points(pdf_bytes: bytes, data_points: list[str]) -> dict[str, dict]:
+    """Extract requested *data_points* from *pdf_bytes*.
 
-        if re.search(r"\bdate\b", key):
-            found = _find_dates(text)
-            value = found[0] if found else ""
+    Returns a mapping of data point name to a result dict holding the value,
+    a heuristic confidence in [0, 1], the 1-based page number, the bounding box
+    on that page, character offsets into the concatenated text and a snippet of
+    the surrounding text. ``candidates`` lists every distinct value found for
+    the data point, best first; the top-level fields mirror ``candidates[0]``.
+    ``status`` is one of the ``STATUS_*`` constants and ``reason`` explains
+    any status other than ``found``.
+    """
+    doc = fitz.open(stream=pdf_bytes, filetype="pdf")
+    try:
+        pages = _extract_pages(doc)
+        text = "\n".join(pages)
+        page_starts: list[int] = []
+        offset = 0
+        for page_text in pages:
+            page_starts.append(offset)
+            offset += len(page_text) + 1
 
-        elif re.search(r"\b(amount|total|price|cost|sum|balance|due)\b", key):
-            found = _find_amounts(text)
-            value = found[0] if found else ""
+        results: dict[str, dict] = {}
+        for dp in data_points:
+            if not dp.strip():
+                results[dp] = _empty(STATUS_SKIPPED, "data point name is blank")
+                continue
+            if not text.strip():
+                results[dp] = _empty(STATUS_SKIPPED, "document has no extractable text")
+                continue
 
-        elif re.search(r"\b(company|client|vendor|supplier|organization|organisation)\b", key):
-            found = _find_company_names(text)
-            value = found[0] if found else ""
+            try:
+                found = _find(text, dp)
+                if not found:
+                    results[dp] = _empty(STATUS_NOT_FOUND, "no matching value in document")
+                    continue
 
-        else:
-            # Generic: look for the label verbatim in the document.
-            value = _find_by_label(text, dp)
+                candidates = [_locate(doc, page_starts, text, m) for m in _rank(found)]
+            except Exception as exc:  # one bad data point must not sink the others
+                results[dp] = _empty(STATUS_ERROR, f"extraction failed: {exc}")
+                continue
 
-        results[dp] = value
+            if len(candidates) > 1:
+                status, reason = STATUS_AMBIGUOUS, f"{len(candidates)} distinct values found"
+            else:
+                status, reason = STATUS_FOUND, ""
+            results[dp] = {**candidates[0], "candidates": candidates, "status": status, "reason": reason}
 
-    return results
+        return results
+    finally:
+        doc.close()
@@ -3,7 +3,7 @@ import base64
 from fastapi import FastAPI, HTTPException
 from pydantic import BaseModel
 
-from extractor import extract_data_points
+from extractor import count_pages, extract_data_points
 
 app = FastAPI(title="NLP PDF Extractor", version="1.0.0")
 
@@ -13,8 +13,33 @@ class ExtractRequest(BaseModel):
     data_points: list[str]
 
 
+class BoundingBox(BaseModel):
+    x0: float
+    y0: float
+    x1: float
+    y1: float
+
+
+class Candidate(BaseModel):
+    value: str
+    confidence: float
+    page: int
+    bbox: BoundingBox | None = None
+    start_offset: int
+    end_offset: int
+    snippet: str
+
+
+class DataPointDetail(Candidate):
+    candidates: list[Candidate] = []
+    status: str
+    reason: str = ""
+
+
 class ExtractResponse(BaseModel):
     results: dict[str, str]
+    details: dict[str, DataPointDetail]
+    page_count: int
 
 
 @app.get("/health")
@@ -33,8 +58,10 @@ def extract(request: ExtractRequest) -> ExtractResponse:
         raise HTTPException(status_code=400, detail="PDF content is empty.")
 
     try:
-        results = extract_data_points(pdf_bytes, request.data_points)
+        details = extract_data_points(pdf_bytes, request.data_points)
+        page_count = count_pages(pdf_bytes)
     except Exception as exc:
         raise HTTPException(status_code=422, detail=f"Extraction failed: {exc}") from exc
 
-    return ExtractResponse(results=results)
+    results = {dp: d["value"] for dp, d in details.items()}
+    return ExtractResponse(results=results, details=details, page_count=page_count)